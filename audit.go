@@ -0,0 +1,379 @@
+package restapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	"reflect"
+	"strings"
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditCreate AuditAction = "create"
+	AuditUpdate AuditAction = "update"
+	AuditPatch  AuditAction = "patch"
+	AuditDelete AuditAction = "delete"
+)
+
+// AuditEntry records one write to a record.
+type AuditEntry struct {
+	ID        uint            `json:"id"`
+	Resource  string          `json:"resource"`
+	Tenant    string          `json:"-"`
+	RecordKey string          `json:"record_key"`
+	Action    AuditAction     `json:"action"`
+	Actor     string          `json:"actor"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	Diff      json.RawMessage `json:"diff"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type AuditChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// AuditSink stores audit entries within the transaction tx of the change.
+type AuditSink interface {
+	Write(tx *gorm.DB, entry *AuditEntry) error
+}
+
+// AuditHistory is implemented by sinks that can read entries back.
+type AuditHistory interface {
+	// History returns the entries of a record written for tenant, newest first.
+	History(ctx context.Context, resource string, tenant string, key string) ([]AuditEntry, error)
+}
+
+type actorKey struct{}
+
+// WithActor attaches the actor recorded in audit entries to ctx.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func actorFrom(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// Audit records every Create, Update, Patch and Delete of service in sink.
+func Audit[Entity interface{}, Req RequestDTO[*Entity], Res ResponseDTO[Entity]](service Service[Entity, Req, Res], resource string, sink AuditSink) {
+	snapshot := func(ent *Entity) (json.RawMessage, error) {
+		if ent == nil {
+			return json.RawMessage("null"), nil
+		}
+
+		res := service.Response()
+		if err := res.FromEntity(*ent); err != nil {
+			return nil, err
+		}
+
+		return json.Marshal(res)
+	}
+
+	write := func(repo Repository[Entity], action AuditAction, before *Entity, after *Entity) error {
+		ent := before
+		if ent == nil {
+			ent = after
+		}
+
+		from, err := snapshot(before)
+		if err != nil {
+			return err
+		}
+
+		to, err := snapshot(after)
+		if err != nil {
+			return err
+		}
+
+		return writeAudit(repo.DB(), sink, resource, action, ent, from, to)
+	}
+
+	events := service.Hook()
+	events.Create().AfterCallRepo(func(repo Repository[Entity], res Res, entity Entity) error {
+		return write(repo, AuditCreate, nil, &entity)
+	})
+
+	around := func(action AuditAction) func(repo Repository[Entity], entity Entity, next func() error) error {
+		return func(repo Repository[Entity], entity Entity, next func() error) error {
+			key, err := entityKey(repo.DB(), &entity)
+			if err != nil {
+				return err
+			}
+
+			before, err := repo.FindByKey(key)
+			if err != nil {
+				return err
+			}
+
+			if err = next(); err != nil {
+				return err
+			}
+
+			after, err := repo.FindByKey(key)
+			if err != nil {
+				return err
+			}
+
+			return write(repo, action, before, after)
+		}
+	}
+	events.Update().AroundCallRepo(around(AuditUpdate))
+	events.Patch().AroundCallRepo(around(AuditPatch))
+
+	events.Delete().AfterCallRepo(func(repo Repository[Entity], res Res, entity Entity) error {
+		return write(repo, AuditDelete, &entity, nil)
+	})
+}
+
+func writeAudit(db *gorm.DB, sink AuditSink, resource string, action AuditAction, ent interface{}, before json.RawMessage, after json.RawMessage) error {
+	sch, err := parseSchema(db, ent)
+	if err != nil {
+		return err
+	}
+
+	key, err := entityKey(db, ent)
+	if err != nil {
+		return err
+	}
+
+	tenant, err := auditTenant(db)
+	if err != nil {
+		return err
+	}
+
+	entry := &AuditEntry{
+		Resource:  resource,
+		Tenant:    tenant,
+		RecordKey: formatKey(sch, key),
+		Action:    action,
+		Actor:     actorFrom(db.Statement.Context),
+		Before:    before,
+		After:     after,
+		CreatedAt: time.Now(),
+	}
+
+	if entry.Diff, err = diffSnapshots(entry.Before, entry.After); err != nil {
+		return err
+	}
+
+	return sink.Write(db, entry)
+}
+
+func auditTenant(db *gorm.DB) (string, error) {
+	tenant, err := tenantScope(db)
+	if err != nil || tenant == nil {
+		return "", err
+	}
+
+	return fmt.Sprint(tenant.Value), nil
+}
+
+func formatKey(sch *schema.Schema, key interface{}) string {
+	composite, ok := key.(map[string]interface{})
+	if !ok {
+		return fmt.Sprint(key)
+	}
+
+	parts := make([]string, 0, len(sch.PrimaryFields))
+	for _, field := range sch.PrimaryFields {
+		parts = append(parts, fmt.Sprint(composite[field.Name]))
+	}
+
+	return strings.Join(parts, CompositeKeySeparator)
+}
+
+func diffSnapshots(before json.RawMessage, after json.RawMessage) (json.RawMessage, error) {
+	decode := func(raw json.RawMessage) (map[string]interface{}, error) {
+		fields := make(map[string]interface{})
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+		if err := decoder.Decode(&fields); err != nil {
+			return nil, err
+		}
+
+		return fields, nil
+	}
+
+	from, err := decode(before)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := decode(after)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := make(map[string]AuditChange)
+	for name, v := range from {
+		if w, ok := to[name]; !ok || !reflect.DeepEqual(v, w) {
+			diff[name] = AuditChange{From: v, To: to[name]}
+		}
+	}
+
+	for name, w := range to {
+		if _, ok := from[name]; !ok {
+			diff[name] = AuditChange{To: w}
+		}
+	}
+
+	return json.Marshal(diff)
+}
+
+// AuditHistoryAction serves GET /:id/history with the audit entries of the record.
+func AuditHistoryAction[Entity interface{}, Req RequestDTO[*Entity], Res ResponseDTO[Entity]](service Service[Entity, Req, Res], resource string, history AuditHistory, middleware ...fiber.Handler) RouteOption {
+	return Action(fiber.MethodGet, "history", func(ctx *fiber.Ctx, key interface{}) error {
+		uc := ctx.UserContext()
+		repo := service.Repo().WithContext(uc)
+		policy := service.Policy()
+
+		ent, err := repo.Unscoped().FindByKey(key)
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if policy != nil && !policy.CanList(uc) {
+				return Forbidden(errors.New("viewing the history is not allowed"))
+			}
+		case err != nil:
+			return TranslateError(err)
+		case policy != nil && !policy.CanView(uc, ent):
+			return Forbidden(errors.New("viewing the history is not allowed"))
+		}
+
+		model := repo.GetModel()
+		sch, err := parseSchema(repo.DB(), &model)
+		if err != nil {
+			return err
+		}
+
+		tenant, err := auditTenant(repo.DB())
+		if err != nil {
+			return err
+		}
+
+		entries, err := history.History(uc, resource, tenant, formatKey(sch, key))
+		if err != nil {
+			return err
+		}
+
+		if fields, ok := policy.(FieldPolicy); ok {
+			if readable := fields.ReadableFields(uc); readable != nil {
+				for i := range entries {
+					if err = pickSnapshots(&entries[i], readable); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		return ctx.JSON(entries)
+	}, middleware...)
+}
+
+func pickSnapshots(entry *AuditEntry, fields []string) error {
+	for _, raw := range []*json.RawMessage{&entry.Before, &entry.After, &entry.Diff} {
+		picked, err := PickFields(*raw, fields)
+		if err != nil {
+			return err
+		}
+
+		if *raw, err = json.Marshal(picked); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AuditLog is the row GormAuditSink stores an AuditEntry in.
+type AuditLog struct {
+	ID        uint   `gorm:"primaryKey"`
+	Resource  string `gorm:"size:255;index:idx_audit_logs_record"`
+	Tenant    string `gorm:"size:255;index:idx_audit_logs_record"`
+	RecordKey string `gorm:"size:255;index:idx_audit_logs_record"`
+	Action    string `gorm:"size:16"`
+	Actor     string `gorm:"size:255"`
+	Before    string `gorm:"type:text"`
+	After     string `gorm:"type:text"`
+	Diff      string `gorm:"type:text"`
+	CreatedAt time.Time
+}
+
+// GormAuditSink keeps audit entries in the audit_logs table.
+type GormAuditSink struct {
+	db *gorm.DB
+}
+
+func NewGormAuditSink(db *gorm.DB) *GormAuditSink {
+	return &GormAuditSink{db: db}
+}
+
+// Migrate creates or updates the audit_logs table.
+func (s *GormAuditSink) Migrate() error {
+	return s.db.AutoMigrate(&AuditLog{})
+}
+
+func (s *GormAuditSink) Write(tx *gorm.DB, entry *AuditEntry) error {
+	row := AuditLog{
+		Resource:  entry.Resource,
+		Tenant:    entry.Tenant,
+		RecordKey: entry.RecordKey,
+		Action:    string(entry.Action),
+		Actor:     entry.Actor,
+		Before:    string(entry.Before),
+		After:     string(entry.After),
+		Diff:      string(entry.Diff),
+		CreatedAt: entry.CreatedAt,
+	}
+
+	err := tx.Session(&gorm.Session{NewDB: true}).Create(&row).Error
+	if err != nil {
+		return err
+	}
+
+	entry.ID = row.ID
+	return nil
+}
+
+func (s *GormAuditSink) History(ctx context.Context, resource string, tenant string, key string) ([]AuditEntry, error) {
+	rows := make([]AuditLog, 0)
+	err := s.db.WithContext(ctx).
+		Where(map[string]interface{}{"resource": resource, "tenant": tenant, "record_key": key}).
+		Order("id DESC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]AuditEntry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, AuditEntry{
+			ID:        row.ID,
+			Resource:  row.Resource,
+			Tenant:    row.Tenant,
+			RecordKey: row.RecordKey,
+			Action:    AuditAction(row.Action),
+			Actor:     row.Actor,
+			Before:    json.RawMessage(row.Before),
+			After:     json.RawMessage(row.After),
+			Diff:      json.RawMessage(row.Diff),
+			CreatedAt: row.CreatedAt,
+		})
+	}
+
+	return entries, nil
+}
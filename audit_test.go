@@ -0,0 +1,111 @@
+package restapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/miniyus/go-restapi"
+	"github.com/miniyus/gofiber/app"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAudit(t *testing.T) {
+	sink := restapi.NewGormAuditSink(db)
+	if err := sink.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTestService()
+	restapi.Audit[TestEntity, *TestReq, *TestRes](s, "test_entities", sink)
+
+	ctx := restapi.WithActor(context.Background(), "tester")
+	data := makeFakeData(1)[0]
+	create, err := s.CreateContext(ctx, &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data.Name = "audited-" + data.Name
+	if _, err = s.PatchContext(ctx, create.Id, &data, "name"); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := sink.History(ctx, "test_entities", "", fmt.Sprint(create.Id))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 2 || entries[0].Action != restapi.AuditPatch || entries[1].Action != restapi.AuditCreate {
+		t.Fatalf("expected patch and create entries, got %+v", entries)
+	}
+
+	if entries[0].Actor != "tester" {
+		t.Errorf("expected the actor from context, got %q", entries[0].Actor)
+	}
+
+	diff := make(map[string]restapi.AuditChange)
+	if err = json.Unmarshal(entries[0].Diff, &diff); err != nil {
+		t.Fatal(err)
+	}
+
+	if change, ok := diff["name"]; !ok || change.To != data.Name {
+		t.Errorf("expected the name change in the diff, got %s", entries[0].Diff)
+	}
+
+	after := make(map[string]interface{})
+	if err = json.Unmarshal(entries[1].After, &after); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := after["CreatedAt"]; ok {
+		t.Errorf("expected the snapshot of the response DTO, got %s", entries[1].After)
+	}
+}
+
+func TestAuditHistoryAction(t *testing.T) {
+	sink := restapi.NewGormAuditSink(db)
+	if err := sink.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTestService()
+	restapi.Audit[TestEntity, *TestReq, *TestRes](s, "audit_history_tests", sink)
+
+	data := makeFakeData(1)[0]
+	create, err := s.Create(&data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	guarded := newTestHandler()
+	guarded.GetService().SetPolicy(fieldPolicy{})
+	restapi.Route(
+		guarded,
+		restapi.Only(restapi.Find),
+		restapi.AuditHistoryAction[TestEntity, *TestReq, *TestRes](guarded.GetService(), "audit_history_tests", sink),
+	)(app.App().Fiber().Group("/audit-history-tests"))
+
+	test, err := app.App().Test(httptest.NewRequest("GET", fmt.Sprintf("/audit-history-tests/%d/history", create.Id), nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := make([]restapi.AuditEntry, 0)
+	if err = json.NewDecoder(test.Body).Decode(&entries); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("expected the create entry, got %+v", entries)
+	}
+
+	after := make(map[string]interface{})
+	if err = json.Unmarshal(entries[0].After, &after); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := after["name"]; ok || after["id"] == nil {
+		t.Errorf("expected only readable fields, got %s", entries[0].After)
+	}
+}
@@ -0,0 +1,390 @@
+package restapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// BulkMode decides what happens to a batch when some of its items fail.
+type BulkMode int
+
+const (
+	// AllOrNothing rolls back the whole batch when any item fails.
+	AllOrNothing BulkMode = iota
+	// PartialSuccess commits the items that succeeded and reports the rest.
+	PartialSuccess
+)
+
+// BulkPatch is one item of a bulk PATCH with the JSON paths present in its body.
+type BulkPatch[Req interface{}] struct {
+	Key    interface{}
+	Dto    Req
+	Fields []string
+}
+
+type BulkItem[Res interface{}] struct {
+	Index  int      `json:"index"`
+	Status int      `json:"status"`
+	Data   Res      `json:"data,omitempty"`
+	Error  *Problem `json:"error,omitempty"`
+}
+
+// BulkResult reports every item of a batch in request order.
+type BulkResult[Res interface{}] struct {
+	Items     []BulkItem[Res] `json:"items"`
+	Succeeded int             `json:"succeeded"`
+	Failed    int             `json:"failed"`
+	Committed bool            `json:"committed"`
+}
+
+func newBulkResult[Res interface{}](n int) *BulkResult[Res] {
+	items := make([]BulkItem[Res], n)
+	for i := range items {
+		items[i].Index = i
+	}
+
+	return &BulkResult[Res]{Items: items}
+}
+
+func (r *BulkResult[Res]) succeed(i int, res Res, status int) {
+	r.Items[i] = BulkItem[Res]{Index: i, Status: status, Data: res}
+	r.Succeeded++
+}
+
+func (r *BulkResult[Res]) fail(i int, err error) {
+	problem := problemOf(err)
+	r.Items[i] = BulkItem[Res]{Index: i, Status: problem.Status, Error: &problem}
+	r.Failed++
+}
+
+func (r *BulkResult[Res]) hookFailed(i int, err error) {
+	problem := problemOf(err)
+	r.Items[i].Status = problem.Status
+	r.Items[i].Error = &problem
+	r.Succeeded--
+	r.Failed++
+}
+
+func (r *BulkResult[Res]) merge(indexes []int, other *BulkResult[Res]) {
+	for k, item := range other.Items {
+		item.Index = indexes[k]
+		r.Items[indexes[k]] = item
+	}
+
+	r.Succeeded += other.Succeeded
+	r.Failed += other.Failed
+	r.Committed = other.Committed
+}
+
+func (r *BulkResult[Res]) status(success int) int {
+	switch {
+	case r.Failed == 0:
+		return success
+	case !r.Committed:
+		return fiber.StatusUnprocessableEntity
+	}
+
+	return fiber.StatusMultiStatus
+}
+
+// BulkCreate creates every dto in one transaction, each in its own savepoint.
+func (s *GenericService[Entity, Req, Res]) BulkCreate(ctx context.Context, dtos []Req, mode BulkMode) (*BulkResult[Res], error) {
+	return s.bulk(ctx, len(dtos), mode, fiber.StatusCreated, func(repo Repository[Entity], i int) (Res, error) {
+		return s.create(repo, dtos[i])
+	})
+}
+
+func (s *GenericService[Entity, Req, Res]) BulkPatch(ctx context.Context, items []BulkPatch[Req], mode BulkMode) (*BulkResult[Res], error) {
+	return s.bulk(ctx, len(items), mode, fiber.StatusOK, func(repo Repository[Entity], i int) (Res, error) {
+		return s.patch(repo, items[i].Key, items[i].Dto, items[i].Fields)
+	})
+}
+
+// BulkDelete deletes the records of keys; successful items carry the deleted record.
+func (s *GenericService[Entity, Req, Res]) BulkDelete(ctx context.Context, keys []interface{}, mode BulkMode) (*BulkResult[Res], error) {
+	return s.bulk(ctx, len(keys), mode, fiber.StatusOK, func(repo Repository[Entity], i int) (Res, error) {
+		return s.delete(repo, keys[i])
+	})
+}
+
+func (s *GenericService[Entity, Req, Res]) bulk(ctx context.Context, n int, mode BulkMode, status int, call func(repo Repository[Entity], i int) (Res, error)) (*BulkResult[Res], error) {
+	result := newBulkResult[Res](n)
+	err := s.repo.WithContext(ctx).Transaction(func(repo Repository[Entity]) error {
+		for i := 0; i < n; i++ {
+			var res Res
+			err := repo.Transaction(func(repo Repository[Entity]) (err error) {
+				res, err = call(repo, i)
+				return err
+			})
+			if err != nil {
+				result.fail(i, err)
+				continue
+			}
+
+			result.succeed(i, res, status)
+		}
+
+		if mode == AllOrNothing && result.Failed != 0 {
+			return errBulkRollback
+		}
+
+		return nil
+	})
+
+	if err != nil && !errors.Is(err, errBulkRollback) {
+		return nil, err
+	}
+
+	result.Committed = err == nil
+	return result, nil
+}
+
+func (g *GenericHandler[Entity, Req, Res]) SetBulkMode(mode BulkMode) {
+	g.bulkMode = mode
+}
+
+// BulkCreate handles POST /bulk with a JSON array of create bodies.
+func (g *GenericHandler[Entity, Req, Res]) BulkCreate(ctx *fiber.Ctx) error {
+	raws, err := bulkBody(ctx)
+	if err != nil {
+		return g.error(ctx, err)
+	}
+
+	result := newBulkResult[Res](len(raws))
+	reqs := make([]Req, 0, len(raws))
+	indexes := make([]int, 0, len(raws))
+	for i, raw := range raws {
+		req := g.newReq()
+		err = json.Unmarshal(raw, req)
+		if err != nil {
+			result.fail(i, BadRequest(err))
+			continue
+		}
+
+		err = ValidateStruct(ctx, req)
+		if err == nil {
+			err = g.beforeCallService(Create, req)
+		}
+		if err != nil {
+			result.fail(i, err)
+			continue
+		}
+
+		reqs = append(reqs, req)
+		indexes = append(indexes, i)
+	}
+
+	return g.bulk(ctx, Create, result, indexes, func() (*BulkResult[Res], error) {
+		return g.service.BulkCreate(ctx.UserContext(), reqs, g.bulkMode)
+	}, fiber.StatusCreated)
+}
+
+// BulkPatch handles PATCH /bulk with a JSON array of merge patches.
+func (g *GenericHandler[Entity, Req, Res]) BulkPatch(ctx *fiber.Ctx) error {
+	raws, err := bulkBody(ctx)
+	if err != nil {
+		return g.error(ctx, err)
+	}
+
+	result := newBulkResult[Res](len(raws))
+	items := make([]BulkPatch[Req], 0, len(raws))
+	indexes := make([]int, 0, len(raws))
+	for i, raw := range raws {
+		item, err := g.bulkPatchItem(ctx, raw)
+		if err != nil {
+			result.fail(i, err)
+			continue
+		}
+
+		items = append(items, item)
+		indexes = append(indexes, i)
+	}
+
+	return g.bulk(ctx, Patch, result, indexes, func() (*BulkResult[Res], error) {
+		return g.service.BulkPatch(ctx.UserContext(), items, g.bulkMode)
+	}, fiber.StatusOK)
+}
+
+func (g *GenericHandler[Entity, Req, Res]) bulkPatchItem(ctx *fiber.Ctx, raw json.RawMessage) (BulkPatch[Req], error) {
+	item := BulkPatch[Req]{Dto: g.newReq()}
+	var doc map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return item, BadRequest(err)
+	}
+
+	key, err := g.bulkKey(doc["id"])
+	if err != nil {
+		return item, err
+	}
+	delete(doc, "id")
+
+	fields, err := decodePatch(doc, item.Dto)
+	if err != nil {
+		return item, err
+	}
+
+	err = ValidatePartial(ctx, item.Dto, fields)
+	if err != nil {
+		return item, err
+	}
+
+	item.Key, item.Fields = key, fields
+	return item, g.beforeCallService(Patch, item.Dto)
+}
+
+type bulkDeleteBody struct {
+	Ids []interface{} `json:"ids"`
+}
+
+// BulkDelete handles DELETE /bulk for an {"ids": [...]} body or the query filters of GET /.
+func (g *GenericHandler[Entity, Req, Res]) BulkDelete(ctx *fiber.Ctx) error {
+	keys, err := g.bulkDeleteKeys(ctx)
+	if err != nil {
+		return g.error(ctx, err)
+	}
+
+	result := newBulkResult[Res](len(keys))
+	accepted := make([]interface{}, 0, len(keys))
+	indexes := make([]int, 0, len(keys))
+	for i, key := range keys {
+		err = g.beforeCallService(Delete, g.newReq())
+		if err != nil {
+			result.fail(i, err)
+			continue
+		}
+
+		accepted = append(accepted, key)
+		indexes = append(indexes, i)
+	}
+
+	return g.bulk(ctx, Delete, result, indexes, func() (*BulkResult[Res], error) {
+		return g.service.BulkDelete(ctx.UserContext(), accepted, g.bulkMode)
+	}, fiber.StatusOK)
+}
+
+func (g *GenericHandler[Entity, Req, Res]) bulkDeleteKeys(ctx *fiber.Ctx) ([]interface{}, error) {
+	if len(bytes.TrimSpace(ctx.Body())) != 0 {
+		var body bulkDeleteBody
+		decoder := json.NewDecoder(bytes.NewReader(ctx.Body()))
+		decoder.UseNumber()
+		if err := decoder.Decode(&body); err != nil {
+			return nil, BadRequest(err)
+		}
+
+		if len(body.Ids) == 0 {
+			return nil, BadRequest(errors.New("ids must not be empty"))
+		}
+
+		keys := make([]interface{}, 0, len(body.Ids))
+		for _, id := range body.Ids {
+			key, err := g.bulkKey(id)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+		}
+
+		return keys, nil
+	}
+
+	req := g.newReq()
+	filter, err := NewFilter[Entity](ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	filter.unpaged = true
+	filter.Cursor = nil
+
+	// unknown query keys filter nothing and must not select every record
+	narrows, err := filter.narrows(g.service.Repo().WithContext(ctx.UserContext()).DB())
+	if err != nil {
+		return nil, err
+	}
+
+	if !narrows {
+		return nil, BadRequest(errors.New("bulk delete requires ids or a filter on a searchable column"))
+	}
+
+	uc := ctx.UserContext()
+	if policy := g.service.Policy(); policy != nil {
+		uc = withListScope(uc, func(db *gorm.DB) *gorm.DB {
+			return policy.ListScope(ctx.UserContext(), db)
+		})
+	}
+
+	repo := g.service.Repo().WithContext(uc)
+	entities, err := repo.GetByFilter(filter)
+	if err != nil {
+		return nil, TranslateError(err)
+	}
+
+	keys := make([]interface{}, 0, len(entities))
+	for i := range entities {
+		key, err := entityKey(repo.DB(), &entities[i])
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func (g *GenericHandler[Entity, Req, Res]) bulk(ctx *fiber.Ctx, event MethodEvent, result *BulkResult[Res], indexes []int, call func() (*BulkResult[Res], error), success int) error {
+	if g.bulkMode == AllOrNothing && result.Failed != 0 {
+		return ctx.Status(result.status(success)).JSON(result)
+	}
+
+	res, err := call()
+	if err != nil {
+		return g.error(ctx, err)
+	}
+	result.merge(indexes, res)
+
+	if result.Committed {
+		for i, item := range result.Items {
+			if item.Error != nil {
+				continue
+			}
+
+			if err = g.afterCallService(event, item.Data); err != nil {
+				result.hookFailed(i, err)
+			}
+		}
+	}
+
+	return ctx.Status(result.status(success)).JSON(result)
+}
+
+func (g *GenericHandler[Entity, Req, Res]) bulkKey(id interface{}) (interface{}, error) {
+	switch v := id.(type) {
+	case json.Number:
+		return g.parseKey(v.String())
+	case string:
+		return g.parseKey(v)
+	case nil:
+		return nil, BadRequest(errors.New("missing id"))
+	}
+
+	return nil, BadRequest(fmt.Errorf("invalid id '%v'", id))
+}
+
+func bulkBody(ctx *fiber.Ctx) ([]json.RawMessage, error) {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(ctx.Body(), &raws); err != nil {
+		return nil, BadRequest(err)
+	}
+
+	if len(raws) == 0 {
+		return nil, BadRequest(errors.New("bulk body must not be empty"))
+	}
+
+	return raws, nil
+}
@@ -0,0 +1,221 @@
+package restapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Versioned is implemented by entities with a custom optimistic lock column.
+type Versioned interface {
+	VersionColumn() string
+}
+
+type ifMatchKey struct{}
+
+// WithIfMatch attaches the value of an If-Match header to ctx.
+func WithIfMatch(ctx context.Context, ifMatch string) context.Context {
+	return context.WithValue(ctx, ifMatchKey{}, ifMatch)
+}
+
+type versionKey struct{}
+
+func withVersion(ctx context.Context, version *string) context.Context {
+	return context.WithValue(ctx, versionKey{}, version)
+}
+
+func recordVersion(db *gorm.DB, ent interface{}) error {
+	if db.Statement.Context == nil {
+		return nil
+	}
+
+	holder, ok := db.Statement.Context.Value(versionKey{}).(*string)
+	if !ok {
+		return nil
+	}
+
+	version, _, err := versionOf(db, ent)
+	if err != nil {
+		return err
+	}
+
+	*holder = version
+	return nil
+}
+
+func versionColumns(sch *schema.Schema, model interface{}) []string {
+	if field := versionField(sch, model); field != nil && field.DBName != "" {
+		return []string{field.DBName}
+	}
+
+	return nil
+}
+
+func ifMatchFrom(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+
+	ifMatch, ok := ctx.Value(ifMatchKey{}).(string)
+	return ifMatch, ok && ifMatch != ""
+}
+
+// ETag quotes version as a strong entity tag.
+func ETag(version string) string {
+	return strconv.Quote(version)
+}
+
+// MatchETag reports whether an If-None-Match header matches version.
+func MatchETag(header string, version string) bool {
+	return matchETag(header, version, false)
+}
+
+// MatchStrongETag reports whether an If-Match header matches version.
+func MatchStrongETag(header string, version string) bool {
+	return matchETag(header, version, true)
+}
+
+func matchETag(header string, version string, strong bool) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if strings.HasPrefix(tag, "W/") {
+			if strong {
+				continue
+			}
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+
+		if version != "" && tag == ETag(version) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func versionField(sch *schema.Schema, model interface{}) *schema.Field {
+	if versioned, ok := model.(Versioned); ok {
+		if name := versioned.VersionColumn(); name != "" {
+			return sch.LookUpField(name)
+		}
+		return nil
+	}
+
+	if field := sch.LookUpField("Version"); field != nil && field.DBName != "" {
+		switch field.FieldType.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return field
+		}
+	}
+
+	for _, field := range sch.Fields {
+		if field.AutoUpdateTime > 0 && field.DBName != "" {
+			return field
+		}
+	}
+
+	return nil
+}
+
+func isCounter(field *schema.Field) bool {
+	return (field.DataType == schema.Int || field.DataType == schema.Uint) && field.AutoUpdateTime == 0
+}
+
+func versionOf(db *gorm.DB, ent interface{}) (string, *schema.Field, error) {
+	sch, err := parseSchema(db, ent)
+	if err != nil {
+		return "", nil, err
+	}
+
+	field := versionField(sch, ent)
+	if field == nil {
+		return "", nil, nil
+	}
+
+	v, zero := field.ValueOf(db.Statement.Context, reflect.Indirect(reflect.ValueOf(ent)))
+	if zero && !isCounter(field) {
+		return "", field, nil
+	}
+
+	return formatVersion(v), field, nil
+}
+
+func formatVersion(v interface{}) string {
+	switch t := v.(type) {
+	case time.Time:
+		return strconv.FormatInt(t.UnixNano(), 10)
+	case *time.Time:
+		if t == nil {
+			return ""
+		}
+		return strconv.FormatInt(t.UnixNano(), 10)
+	}
+
+	return fmt.Sprint(v)
+}
+
+func checkIfMatch(db *gorm.DB, ent interface{}) error {
+	ifMatch, ok := ifMatchFrom(db.Statement.Context)
+	if !ok {
+		return nil
+	}
+
+	version, _, err := versionOf(db, ent)
+	if err != nil {
+		return err
+	}
+
+	if !MatchStrongETag(ifMatch, version) {
+		return PreconditionFailed(errors.New("resource has been modified"))
+	}
+
+	return nil
+}
+
+func lockVersion(db *gorm.DB, current interface{}, ent interface{}) (*gorm.DB, *schema.Field, error) {
+	version, field, err := versionOf(db, current)
+	if err != nil || field == nil || version == "" {
+		return db, nil, err
+	}
+
+	// a zero version means ent wasn't read from the database
+	if _, zero := field.ValueOf(db.Statement.Context, reflect.Indirect(reflect.ValueOf(ent))); !zero {
+		expected, _, err := versionOf(db, ent)
+		if err != nil {
+			return db, nil, err
+		}
+
+		if expected != version {
+			return db, nil, PreconditionFailed(errors.New("resource has been modified"))
+		}
+	}
+
+	v, _ := field.ValueOf(db.Statement.Context, reflect.Indirect(reflect.ValueOf(current)))
+	db = db.Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: v})
+
+	if isCounter(field) {
+		next, err := strconv.ParseUint(version, 10, 64)
+		if err != nil {
+			return db, nil, err
+		}
+
+		err = field.Set(db.Statement.Context, reflect.Indirect(reflect.ValueOf(ent)), next+1)
+		if err != nil {
+			return db, nil, err
+		}
+	}
+
+	return db, field, nil
+}
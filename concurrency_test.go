@@ -0,0 +1,119 @@
+package restapi_test
+
+import (
+	"bytes"
+	"github.com/gofiber/fiber/v2"
+	"github.com/miniyus/go-restapi"
+	"github.com/miniyus/gofiber/app"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMatchETag(t *testing.T) {
+	testCases := []struct {
+		header  string
+		version string
+		match   bool
+	}{
+		{`"3"`, "3", true},
+		{`W/"2", "3"`, "3", true},
+		{`*`, "3", true},
+		{`*`, "", true},
+		{`"2"`, "3", false},
+		{``, "3", false},
+	}
+
+	for _, tc := range testCases {
+		if restapi.MatchETag(tc.header, tc.version) != tc.match {
+			t.Errorf("%s against %s: expected %v", tc.header, tc.version, tc.match)
+		}
+	}
+
+	if restapi.MatchStrongETag(`W/"3"`, "3") {
+		t.Error("If-Match must not match weak tags")
+	}
+
+	if !restapi.MatchStrongETag(`W/"2", "3"`, "3") {
+		t.Error("If-Match should match a strong tag in the list")
+	}
+}
+
+func TestGenericHandler_ETag(t *testing.T) {
+	app.App().Fiber().Get("/etag-tests/:id", h.Find)
+	app.App().Fiber().Patch("/etag-tests/:id", h.Patch)
+
+	test, err := app.App().Test(httptest.NewRequest("GET", "/etag-tests/1", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	etag := test.Header.Get(fiber.HeaderETag)
+	if etag == "" {
+		t.Fatal("missing ETag")
+	}
+
+	req := httptest.NewRequest("GET", "/etag-tests/1", nil)
+	req.Header.Set(fiber.HeaderIfNoneMatch, etag)
+	test, err = app.App().Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if test.StatusCode != fiber.StatusNotModified {
+		t.Errorf("expected 304, got %d", test.StatusCode)
+	}
+
+	patch := func(ifMatch string) int {
+		req := httptest.NewRequest("PATCH", "/etag-tests/1", bytes.NewReader([]byte(`{"name":"etag-name"}`)))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		req.Header.Set(fiber.HeaderIfMatch, ifMatch)
+		test, err := app.App().Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return test.StatusCode
+	}
+
+	if status := patch(`"stale"`); status != fiber.StatusPreconditionFailed {
+		t.Errorf("expected 412 for stale If-Match, got %d", status)
+	}
+
+	if status := patch(etag); status != fiber.StatusOK {
+		t.Errorf("expected 200 for current If-Match, got %d", status)
+	}
+
+	if status := patch(etag); status != fiber.StatusPreconditionFailed {
+		t.Errorf("expected 412 after the record changed, got %d", status)
+	}
+}
+
+func TestGenericHandler_UpdateETag(t *testing.T) {
+	app.App().Fiber().Get("/etag-tests/:id", h.Find)
+	app.App().Fiber().Put("/etag-tests/:id", h.Update)
+
+	etag := func() string {
+		test, err := app.App().Test(httptest.NewRequest("GET", "/etag-tests/4", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return test.Header.Get(fiber.HeaderETag)
+	}
+
+	req := httptest.NewRequest("PUT", "/etag-tests/4", bytes.NewReader([]byte(`{"name":"etag-put","test_relation":{"seq":1}}`)))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	req.Header.Set(fiber.HeaderIfMatch, etag())
+	test, err := app.App().Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if test.StatusCode != fiber.StatusOK {
+		t.Fatalf("expected 200, got %d", test.StatusCode)
+	}
+
+	if put, stored := test.Header.Get(fiber.HeaderETag), etag(); put != stored {
+		t.Errorf("expected the ETag of the stored row %s, got %s", stored, put)
+	}
+}
@@ -0,0 +1,229 @@
+package restapi
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+	"reflect"
+)
+
+// Cursor holds the state of keyset pagination.
+type Cursor struct {
+	values   []json.RawMessage
+	backward bool
+	keys     []*keyColumn
+	next     string
+	prev     string
+}
+
+type cursorToken struct {
+	Values   []json.RawMessage `json:"v"`
+	Backward bool              `json:"b,omitempty"`
+}
+
+type keyColumn struct {
+	field *schema.Field
+	desc  bool
+}
+
+// DecodeCursor parses an opaque ?cursor= token. An empty token starts at the first page.
+func DecodeCursor(token string) (*Cursor, error) {
+	if token == "" {
+		return &Cursor{}, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, BadRequest(errors.New("malformed cursor"))
+	}
+
+	var decoded cursorToken
+	if err = json.Unmarshal(raw, &decoded); err != nil {
+		return nil, BadRequest(errors.New("malformed cursor"))
+	}
+
+	return &Cursor{
+		values:   decoded.Values,
+		backward: decoded.Backward,
+	}, nil
+}
+
+func (c *Cursor) Next() string {
+	return c.next
+}
+
+func (c *Cursor) Prev() string {
+	return c.prev
+}
+
+func (c *Cursor) encode(entity reflect.Value, backward bool) (string, error) {
+	values := make([]json.RawMessage, 0, len(c.keys))
+	for _, key := range c.keys {
+		v, _ := key.field.ValueOf(context.Background(), entity)
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		values = append(values, b)
+	}
+
+	b, err := json.Marshal(cursorToken{Values: values, Backward: backward})
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// KeysetPaginate restricts the query to the rows after the cursor position.
+func (f *Filter[Entity]) KeysetPaginate(db *gorm.DB) (*gorm.DB, error) {
+	sch, err := parseSchema(db, db.Statement.Model)
+	if err != nil {
+		return db, err
+	}
+
+	if sch.PrioritizedPrimaryField == nil {
+		return db, errors.New("cursor pagination requires a primary key")
+	}
+
+	c := f.Cursor
+	c.keys = make([]*keyColumn, 0, len(f.Sorter.columns)+1)
+	hasPk := false
+	for _, col := range f.Sorter.columns {
+		if !f.Sorter.isAllowed(col.Column) {
+			return db, BadRequest(fmt.Errorf("sort on column '%s' is not allowed", col.Column))
+		}
+
+		field := sch.LookUpField(col.Column)
+		if field == nil || field.DBName == "" {
+			return db, BadRequest(fmt.Errorf("column '%s' can't be used with cursor pagination", col.Column))
+		}
+
+		if nullable(field) {
+			return db, BadRequest(fmt.Errorf("column '%s' is nullable and can't be used with cursor pagination", col.Column))
+		}
+
+		hasPk = hasPk || field == sch.PrioritizedPrimaryField
+		c.keys = append(c.keys, &keyColumn{field: field, desc: col.Desc})
+	}
+
+	if !hasPk {
+		c.keys = append(c.keys, &keyColumn{field: sch.PrioritizedPrimaryField})
+	}
+
+	if len(c.values) != 0 {
+		if len(c.values) != len(c.keys) {
+			return db, BadRequest(errors.New("cursor does not match sort columns"))
+		}
+
+		expr, err := c.where()
+		if err != nil {
+			return db, err
+		}
+
+		db = db.Where(expr)
+	}
+
+	for _, key := range c.keys {
+		db = db.Order(clause.OrderByColumn{
+			Column: clause.Column{Table: clause.CurrentTable, Name: key.field.DBName},
+			Desc:   key.desc != c.backward,
+		})
+	}
+
+	return db.Limit(f.pageSize() + 1), nil
+}
+
+func (c *Cursor) where() (clause.Expression, error) {
+	values := make([]interface{}, 0, len(c.keys))
+	for i, key := range c.keys {
+		ptr := reflect.New(key.field.FieldType)
+		if err := json.Unmarshal(c.values[i], ptr.Interface()); err != nil {
+			return nil, BadRequest(errors.New("malformed cursor"))
+		}
+		values = append(values, ptr.Elem().Interface())
+	}
+
+	ors := make([]clause.Expression, 0, len(c.keys))
+	for i, key := range c.keys {
+		ands := make([]clause.Expression, 0, i+1)
+		for j := 0; j < i; j++ {
+			ands = append(ands, clause.Eq{Column: c.column(j), Value: values[j]})
+		}
+
+		if key.desc != c.backward {
+			ands = append(ands, clause.Lt{Column: c.column(i), Value: values[i]})
+		} else {
+			ands = append(ands, clause.Gt{Column: c.column(i), Value: values[i]})
+		}
+
+		ors = append(ors, clause.And(ands...))
+	}
+
+	return clause.Or(ors...), nil
+}
+
+func nullable(field *schema.Field) bool {
+	if field.PrimaryKey || field.NotNull {
+		return false
+	}
+
+	if field.FieldType.Kind() == reflect.Ptr {
+		return true
+	}
+
+	valuer := reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+	return field.FieldType.Implements(valuer) || reflect.PtrTo(field.FieldType).Implements(valuer)
+}
+
+func (c *Cursor) column(i int) clause.Column {
+	return clause.Column{Table: clause.CurrentTable, Name: c.keys[i].field.DBName}
+}
+
+func (f *Filter[Entity]) keysetResult(entities []Entity) ([]Entity, error) {
+	c := f.Cursor
+	size := f.pageSize()
+	hasMore := len(entities) > size
+	if hasMore {
+		entities = entities[:size]
+	}
+
+	if c.backward {
+		for i, j := 0, len(entities)-1; i < j; i, j = i+1, j-1 {
+			entities[i], entities[j] = entities[j], entities[i]
+		}
+	}
+
+	c.next, c.prev = "", ""
+	if len(entities) == 0 {
+		return entities, nil
+	}
+
+	var err error
+	first := reflect.ValueOf(&entities[0]).Elem()
+	last := reflect.ValueOf(&entities[len(entities)-1]).Elem()
+
+	if hasMore || c.backward {
+		if c.next, err = c.encode(last, false); err != nil {
+			return entities, err
+		}
+	}
+
+	if (c.backward && hasMore) || (!c.backward && len(c.values) != 0) {
+		if c.prev, err = c.encode(first, true); err != nil {
+			return entities, err
+		}
+	}
+
+	return entities, nil
+}
+
+func (f *Filter[Entity]) pageSize() int {
+	return clampPageSize(f.Page.PageSize)
+}
@@ -0,0 +1,97 @@
+package restapi_test
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/miniyus/go-restapi"
+	"github.com/miniyus/gofiber/app"
+	"io"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestDecodeCursor(t *testing.T) {
+	cursor, err := restapi.DecodeCursor("")
+	if err != nil {
+		t.Error(err)
+	}
+
+	if cursor.Next() != "" || cursor.Prev() != "" {
+		t.Error("empty cursor should have no tokens")
+	}
+
+	_, err = restapi.DecodeCursor("not a cursor")
+	if !errors.Is(err, restapi.ErrBadRequest) {
+		t.Errorf("expected bad request, got %v", err)
+	}
+}
+
+func TestGenericHandler_AllCursor(t *testing.T) {
+	app.App().Fiber().Get("/tests", h.All)
+
+	seen := make(map[uint]bool)
+	next := ""
+	for i := 0; i < 100; i++ {
+		req := httptest.NewRequest("GET", "/tests?page_size=3&sort=-name&cursor="+url.QueryEscape(next), nil)
+		test, err := app.App().Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if test.StatusCode != fiber.StatusOK {
+			t.Fatalf("unexpected status %d", test.StatusCode)
+		}
+
+		body, err := io.ReadAll(test.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		page := restapi.Paginated[TestRes]{}
+		if err = json.Unmarshal(body, &page); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, res := range page.Data {
+			if seen[res.Id] {
+				t.Errorf("duplicated row %d", res.Id)
+			}
+			seen[res.Id] = true
+		}
+
+		if page.NextCursor == "" {
+			break
+		}
+		next = page.NextCursor
+	}
+
+	t.Logf("visited %d rows", len(seen))
+}
+
+func TestGenericHandler_AllCursorNoTotal(t *testing.T) {
+	app.App().Fiber().Get("/tests", h.All)
+
+	req := httptest.NewRequest("GET", "/tests?page_size=3&cursor=", nil)
+	test, err := app.App().Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := io.ReadAll(test.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	page := make(map[string]interface{})
+	if err = json.Unmarshal(body, &page); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"total", "page", "total_pages"} {
+		if _, ok := page[key]; ok {
+			t.Errorf("keyset page should not have %s: %s", key, body)
+		}
+	}
+}
@@ -8,8 +8,8 @@ import (
 	"github.com/miniyus/gofiber/pagination"
 	"github.com/miniyus/structs"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"reflect"
-	"strings"
 )
 
 type RequestDTO[Entity interface{}] interface {
@@ -20,43 +20,136 @@ type ResponseDTO[Entity interface{}] interface {
 	FromEntity(ent Entity) error
 }
 
+type DTOFactory[T interface{}] func() T
+
+// NewDTOFactory returns a factory that allocates a fresh value of proto's type on every call.
+func NewDTOFactory[T interface{}](proto T) DTOFactory[T] {
+	ty := reflect.TypeOf(proto)
+	if ty == nil || ty.Kind() != reflect.Ptr {
+		return func() T {
+			return proto
+		}
+	}
+
+	return func() T {
+		return reflect.New(ty.Elem()).Interface().(T)
+	}
+}
+
 type Sorter struct {
-	columns []string
+	columns []SortColumn
+	allowed []string
 }
 
-func (s *Sorter) Sort(db *gorm.DB) *gorm.DB {
+func (s *Sorter) Sort(db *gorm.DB) (*gorm.DB, error) {
+	if len(s.columns) == 0 {
+		return db, nil
+	}
+
+	sch, err := parseSchema(db, db.Statement.Model)
+	if err != nil {
+		return db, err
+	}
+
 	for _, col := range s.columns {
-		if col == "" {
-			continue
+		if !s.isAllowed(col.Column) {
+			return db, BadRequest(fmt.Errorf("sort on column '%s' is not allowed", col.Column))
 		}
-		order := strings.Split(col, " ")
-		if len(order) != 0 {
-			db = db.Order(fmt.Sprintf("%s %s", order[0], order[1]))
+
+		var column clause.Column
+		db, column, err = resolveColumn(db, sch, col.Column)
+		if err != nil {
+			return db, err
 		}
+
+		db = db.Order(clause.OrderByColumn{Column: column, Desc: col.Desc})
 	}
-	return db
+
+	return db, nil
+}
+
+func (s *Sorter) isAllowed(col string) bool {
+	if s.allowed == nil {
+		return true
+	}
+
+	for _, allowed := range s.allowed {
+		if allowed == col {
+			return true
+		}
+	}
+
+	return false
 }
 
 type Searcher[Entity interface{}] struct {
-	req    RequestDTO[*Entity]
-	entity Entity
+	req        RequestDTO[*Entity]
+	entity     Entity
+	conditions []Condition
 }
 
 func (s *Searcher[Entity]) SetEntity(ent Entity) {
 	s.entity = ent
 }
 
-func (s *Searcher[Entity]) Search(db *gorm.DB) (*gorm.DB, error) {
+func (s *Searcher[Entity]) narrows(db *gorm.DB) (bool, error) {
+	if len(s.conditions) != 0 {
+		return true, nil
+	}
+
 	if s.req == nil {
+		return false, nil
+	}
+
+	ent := s.entity
+	if err := s.req.ToEntity(&ent); err != nil {
+		return false, err
+	}
+
+	sch, err := parseSchema(db, &ent)
+	if err != nil {
+		return false, err
+	}
+
+	rv := reflect.ValueOf(&ent).Elem()
+	for _, field := range sch.Fields {
+		if field.DBName == "" {
+			continue
+		}
+
+		if _, zero := field.ValueOf(db.Statement.Context, rv); !zero {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (s *Searcher[Entity]) Search(db *gorm.DB) (*gorm.DB, error) {
+	if s.req != nil {
+		err := s.req.ToEntity(&s.entity)
+		if err != nil {
+			return db, err
+		}
+
+		db = db.Where(s.entity)
+	}
+
+	if len(s.conditions) == 0 {
 		return db, nil
 	}
 
-	err := s.req.ToEntity(&s.entity)
+	sch, err := parseSchema(db, &s.entity)
 	if err != nil {
 		return db, err
 	}
 
-	db.Where(s.entity)
+	for _, cond := range s.conditions {
+		db, err = cond.Apply(db, sch)
+		if err != nil {
+			return db, err
+		}
+	}
 
 	return db, nil
 }
@@ -65,24 +158,81 @@ type Filter[Entity interface{}] struct {
 	pagination.Page
 	Sorter
 	Searcher[Entity]
+	Includer
+	Cursor  *Cursor
+	Trashed TrashedMode
+	Select  []string
+	unpaged bool
+}
+
+// Paginate limits db to one page, never larger than MaxPageSize.
+func (f *Filter[Entity]) Paginate(db *gorm.DB) *gorm.DB {
+	if f.unpaged {
+		return db
+	}
+
+	page := f.Page.Page
+	if page < 1 {
+		page = 1
+	}
+
+	size := f.pageSize()
+	return db.Offset((page - 1) * size).Limit(size)
 }
 
 func NewFilter[Entity interface{}](ctx *fiber.Ctx, req RequestDTO[*Entity]) (*Filter[Entity], error) {
-	param := ctx.Query("sort")
-	sort := strings.Split(param, ",")
-	fromCtx, _ := pagination.GetPageFromCtx(ctx)
+	fromCtx, err := pagination.GetPageFromCtx(ctx)
+	if err != nil {
+		fromCtx = pagination.Page{
+			Page:     ctx.QueryInt("page", 1),
+			PageSize: ctx.QueryInt("page_size", DefaultPageSize),
+		}
+	}
+	fromCtx.PageSize = clampPageSize(fromCtx.PageSize)
+
+	err = ctx.QueryParser(req)
+	if err != nil {
+		return nil, BadRequest(err)
+	}
+
+	var columns []string
+	if searchable, ok := req.(Searchable); ok {
+		columns = searchable.SearchColumns()
+	}
+
+	conditions, err := ParseConditions(ctx, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	sorter := Sorter{columns: ParseSort(ctx.Query("sort"))}
+	if sortable, ok := req.(Sortable); ok {
+		sorter.allowed = sortable.SortColumns()
+	}
+
+	var cursor *Cursor
+	if ctx.Context().QueryArgs().Has("cursor") {
+		cursor, err = DecodeCursor(ctx.Query("cursor"))
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	err := ctx.QueryParser(req)
+	trashed, err := ParseTrashed(ctx.Query("trashed"))
 	if err != nil {
 		return nil, err
 	}
 
 	return &Filter[Entity]{
 		Page:   fromCtx,
-		Sorter: Sorter{columns: sort},
+		Sorter: sorter,
 		Searcher: Searcher[Entity]{
-			req: req,
+			req:        req,
+			conditions: conditions,
 		},
+		Includer: NewIncluder(ctx.Query("include"), req),
+		Cursor:   cursor,
+		Trashed:  trashed,
 	}, nil
 }
 
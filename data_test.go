@@ -2,10 +2,13 @@ package restapi_test
 
 import (
 	"github.com/go-faker/faker/v4"
+	"github.com/gofiber/fiber/v2"
 	"github.com/miniyus/go-restapi"
 	"github.com/miniyus/gofiber/app"
 	"github.com/miniyus/gofiber/database"
 	"gorm.io/gorm"
+	"net/http/httptest"
+	"testing"
 )
 
 type TestEntity struct {
@@ -39,6 +42,10 @@ func (tr *TestReq) ToEntity(ent *TestEntity) error {
 	return nil
 }
 
+func (tr *TestReq) SearchColumns() []string {
+	return []string{"id", "name", "created_at", "deleted_at"}
+}
+
 type TestRelationRes struct {
 	Id           uint `json:"id"`
 	TestEntityId uint `json:"test_entity_id"`
@@ -94,6 +101,14 @@ func init() {
 	db.Debug().Exec("UPDATE SQLITE_SEQUENCE SET seq = 0 WHERE name = 'test_relation_models';")
 }
 
+func newTestService() restapi.Service[TestEntity, *TestReq, *TestRes] {
+	return restapi.NewService[TestEntity, *TestReq, *TestRes](restapi.NewRepository[TestEntity](db, TestEntity{}), &TestRes{})
+}
+
+func newTestHandler() restapi.Handler[TestEntity, *TestReq, *TestRes] {
+	return restapi.NewHandler[TestEntity, *TestReq, *TestRes](&TestReq{}, newTestService())
+}
+
 func makeFakeData(cnt int) []TestReq {
 	testData := make([]TestReq, 0)
 	for i := 0; i < cnt; i++ {
@@ -111,3 +126,34 @@ func makeFakeData(cnt int) []TestReq {
 
 	return testData
 }
+
+func TestNewDTOFactory(t *testing.T) {
+	newReq := restapi.NewDTOFactory(&TestReq{Name: "proto"})
+	a := newReq()
+	b := newReq()
+	if a == b {
+		t.Error("factory returned shared instance")
+	}
+
+	a.Name = "changed"
+	if b.Name != "" {
+		t.Errorf("fields leaked between instances: %s", b.Name)
+	}
+}
+
+func TestNewFilter_MalformedQuery(t *testing.T) {
+	f := fiber.New(fiber.Config{ErrorHandler: restapi.ErrorHandler})
+	f.Get("/", func(ctx *fiber.Ctx) error {
+		_, err := restapi.NewFilter[TestRelationModel](ctx, &TestRelationReq{})
+		return err
+	})
+
+	test, err := f.Test(httptest.NewRequest("GET", "/?TestEntityId=abc", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if test.StatusCode != fiber.StatusBadRequest {
+		t.Errorf("expected 400 for a malformed query value, got %d", test.StatusCode)
+	}
+}
@@ -0,0 +1,193 @@
+package restapi
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/miniyus/gofiber/log"
+	"gorm.io/gorm"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const ProblemContentType = "application/problem+json"
+
+var (
+	ErrBadRequest = errors.New("bad request")
+	ErrNotFound   = errors.New("resource not found")
+	ErrConflict   = errors.New("resource conflict")
+	ErrValidation = errors.New("validation failed")
+	ErrForbidden  = errors.New("forbidden")
+
+	ErrPreconditionFailed = errors.New("precondition failed")
+
+	ErrRepoCallSkipped = errors.New("repository call skipped by around hook")
+	errResponded       = errors.New("response written by around hook")
+	errBulkRollback    = errors.New("bulk request rolled back")
+)
+
+var errorStatus = map[error]int{
+	ErrBadRequest: fiber.StatusBadRequest,
+	ErrNotFound:   fiber.StatusNotFound,
+	ErrConflict:   fiber.StatusConflict,
+	ErrValidation: fiber.StatusUnprocessableEntity,
+	ErrForbidden:  fiber.StatusForbidden,
+
+	ErrPreconditionFailed: fiber.StatusPreconditionFailed,
+}
+
+// Error is a typed failure that GenericHandler renders as an RFC 7807 problem.
+type Error struct {
+	Kind   error
+	Detail string
+	Errors interface{}
+	Err    error
+}
+
+func NewError(kind error, detail string, err error) *Error {
+	return &Error{
+		Kind:   kind,
+		Detail: detail,
+		Err:    err,
+	}
+}
+
+func NotFound(err error) *Error {
+	return NewError(ErrNotFound, errDetail(err), err)
+}
+
+func Conflict(err error) *Error {
+	return NewError(ErrConflict, errDetail(err), err)
+}
+
+func BadRequest(err error) *Error {
+	return NewError(ErrBadRequest, errDetail(err), err)
+}
+
+func Forbidden(err error) *Error {
+	return NewError(ErrForbidden, errDetail(err), err)
+}
+
+func PreconditionFailed(err error) *Error {
+	return NewError(ErrPreconditionFailed, errDetail(err), err)
+}
+
+func Validation(errs interface{}) *Error {
+	e := NewError(ErrValidation, "", nil)
+	e.Errors = errs
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.Detail != "" {
+		return e.Kind.Error() + ": " + e.Detail
+	}
+
+	return e.Kind.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Is(target error) bool {
+	return e.Kind == target
+}
+
+func (e *Error) Status() int {
+	if status, ok := errorStatus[e.Kind]; ok {
+		return status
+	}
+
+	return fiber.StatusInternalServerError
+}
+
+// TranslateError maps repository and parsing failures onto typed errors.
+func TranslateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var typed *Error
+	if errors.As(err, &typed) {
+		return typed
+	}
+
+	var numErr *strconv.NumError
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return NotFound(err)
+	case errors.Is(err, gorm.ErrDuplicatedKey), isUniqueViolation(err):
+		// driver messages name tables and columns, keep them in the log
+		log.GetLogger().Error(err)
+		return NewError(ErrConflict, "resource already exists", err)
+	case errors.As(err, &numErr):
+		return BadRequest(err)
+	}
+
+	return err
+}
+
+func isUniqueViolation(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "unique constraint") ||
+		strings.Contains(msg, "duplicate entry") ||
+		strings.Contains(msg, "duplicate key")
+}
+
+func errDetail(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return err.Error()
+}
+
+type Problem struct {
+	Type     string      `json:"type"`
+	Title    string      `json:"title"`
+	Status   int         `json:"status"`
+	Detail   string      `json:"detail,omitempty"`
+	Instance string      `json:"instance,omitempty"`
+	Errors   interface{} `json:"errors,omitempty"`
+}
+
+func NewProblem(ctx *fiber.Ctx, err error) Problem {
+	problem := problemOf(err)
+	problem.Instance = ctx.OriginalURL()
+	return problem
+}
+
+func problemOf(err error) Problem {
+	status := fiber.StatusInternalServerError
+	detail := ""
+	var errs interface{}
+
+	var typed *Error
+	var fiberErr *fiber.Error
+	switch {
+	case errors.As(TranslateError(err), &typed):
+		status = typed.Status()
+		detail = typed.Detail
+		errs = typed.Errors
+	case errors.As(err, &fiberErr):
+		status = fiberErr.Code
+		detail = fiberErr.Message
+	}
+
+	return Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Errors: errs,
+	}
+}
+
+// ErrorHandler writes err as an application/problem+json response.
+func ErrorHandler(ctx *fiber.Ctx, err error) error {
+	problem := NewProblem(ctx, err)
+	err = ctx.Status(problem.Status).JSON(problem)
+	ctx.Set(fiber.HeaderContentType, ProblemContentType)
+	return err
+}
@@ -0,0 +1,43 @@
+package restapi_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/miniyus/go-restapi"
+	"gorm.io/gorm"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTranslateError(t *testing.T) {
+	_, numErr := strconv.ParseUint("abc", 10, 64)
+	testCases := []struct {
+		err  error
+		kind error
+	}{
+		{gorm.ErrRecordNotFound, restapi.ErrNotFound},
+		{fmt.Errorf("find: %w", gorm.ErrRecordNotFound), restapi.ErrNotFound},
+		{errors.New("UNIQUE constraint failed: test_entities.name"), restapi.ErrConflict},
+		{numErr, restapi.ErrBadRequest},
+		{restapi.Forbidden(nil), restapi.ErrForbidden},
+	}
+
+	for _, testCase := range testCases {
+		err := restapi.TranslateError(testCase.err)
+		if !errors.Is(err, testCase.kind) {
+			t.Errorf("%v: expected %v, got %v", testCase.err, testCase.kind, err)
+		}
+	}
+
+	var typed *restapi.Error
+	conflict := restapi.TranslateError(errors.New("UNIQUE constraint failed: test_entities.name"))
+	if !errors.As(conflict, &typed) || strings.Contains(typed.Detail, "test_entities") {
+		t.Errorf("conflict detail leaks the driver message: %v", conflict)
+	}
+
+	unknown := errors.New("unknown")
+	if restapi.TranslateError(unknown) != unknown {
+		t.Error("unknown error should pass through")
+	}
+}
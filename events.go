@@ -3,18 +3,20 @@ package restapi
 import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/miniyus/gollection"
+	"sort"
 )
 
 type MethodEvent string
 
 const (
-	Common MethodEvent = "ALL"
-	Create MethodEvent = "C"
-	All    MethodEvent = "RA"
-	Find   MethodEvent = "R"
-	Update MethodEvent = "UA"
-	Patch  MethodEvent = "U"
-	Delete MethodEvent = "D"
+	Common  MethodEvent = "ALL"
+	Create  MethodEvent = "C"
+	All     MethodEvent = "RA"
+	Find    MethodEvent = "R"
+	Update  MethodEvent = "UA"
+	Patch   MethodEvent = "U"
+	Delete  MethodEvent = "D"
+	Restore MethodEvent = "RS"
 )
 
 type Event string
@@ -25,6 +27,8 @@ const (
 	AfterCallServiceEvent  Event = "afterCallService"
 	BeforeCallRepoEvent    Event = "beforeCallRepo"
 	AfterCallRepoEvent     Event = "afterCallRepo"
+	AroundCallServiceEvent Event = "aroundCallService"
+	AroundCallRepoEvent    Event = "aroundCallRepo"
 )
 
 type HandlerHook[Entity interface{}, Req RequestDTO[*Entity], Res ResponseDTO[Entity]] interface {
@@ -35,44 +39,96 @@ type ServiceHook[Entity interface{}, Req RequestDTO[*Entity], Res ResponseDTO[En
 	Hook() *HasServiceEvent[Entity, Req, Res]
 }
 
+// HandlerEvents registers handler hooks, run in ascending priority.
 type HandlerEvents[Entity interface{}, Req RequestDTO[*Entity], Res ResponseDTO[Entity]] interface {
-	ParseRequest(pr func(ctx *fiber.Ctx, dto Req) error)
-	BeforeCallService(bs func(dto Req) error)
-	AfterCallService(as func(dto Res) error)
+	ParseRequest(pr func(ctx *fiber.Ctx, dto Req) error, priority ...int)
+	BeforeCallService(bs func(dto Req) error, priority ...int)
+	AfterCallService(as func(dto Res) error, priority ...int)
+	AroundCallService(as func(ctx *fiber.Ctx, next func() error) error, priority ...int)
 }
 
+// ServiceEvents registers service hooks, ordered like HandlerEvents.
 type ServiceEvents[Entity interface{}, Req RequestDTO[*Entity], Res ResponseDTO[Entity]] interface {
-	BeforeCallRepo(br func(dto Req, entity Entity) error)
-	AfterCallRepo(ar func(dto Res, entity Entity) error)
+	BeforeCallRepo(br func(repo Repository[Entity], dto Req, entity Entity) error, priority ...int)
+	AfterCallRepo(ar func(repo Repository[Entity], dto Res, entity Entity) error, priority ...int)
+	AroundCallRepo(ar func(repo Repository[Entity], entity Entity, next func() error) error, priority ...int)
 }
 
 type Features[Entity interface{}, Req RequestDTO[*Entity], Res ResponseDTO[Entity]] struct {
-	parseRequest      *ParseRequest[Entity, Req]
-	beforeCallService *BeforeCallService[Entity, Req, Res]
-	afterCallService  *AfterCallService[Entity, Res]
-	beforeCallRepo    *BeforeCallRepo[Entity, Req, Res]
-	afterCallRepo     *AfterCallRepo[Entity, Res]
+	parseRequest      []*ParseRequest[Entity, Req]
+	beforeCallService []*BeforeCallService[Entity, Req, Res]
+	afterCallService  []*AfterCallService[Entity, Res]
+	aroundCallService []*AroundCallService[Entity, Req]
+	beforeCallRepo    []*BeforeCallRepo[Entity, Req, Res]
+	afterCallRepo     []*AfterCallRepo[Entity, Res]
+	aroundCallRepo    []*AroundCallRepo[Entity]
 	methodEvent       MethodEvent
 }
 
-func (f *Features[Entity, Req, Res]) ParseRequest(pr func(ctx *fiber.Ctx, dto Req) error) {
-	f.parseRequest = NewParseRequest[Entity, Req](pr)
+func (f *Features[Entity, Req, Res]) ParseRequest(pr func(ctx *fiber.Ctx, dto Req) error, priority ...int) {
+	hook := NewParseRequest[Entity, Req](pr)
+	hook.priority = priorityOf(priority)
+	f.parseRequest = insertHook(f.parseRequest, hook)
 }
 
-func (f *Features[Entity, Req, Res]) BeforeCallService(bs func(dto Req) error) {
-	f.beforeCallService = NewBeforeCallService[Entity, Req, Res](bs)
+func (f *Features[Entity, Req, Res]) BeforeCallService(bs func(dto Req) error, priority ...int) {
+	hook := NewBeforeCallService[Entity, Req, Res](bs)
+	hook.priority = priorityOf(priority)
+	f.beforeCallService = insertHook(f.beforeCallService, hook)
 }
 
-func (f *Features[Entity, Req, Res]) AfterCallService(as func(dto Res) error) {
-	f.afterCallService = NewAfterCallService[Entity, Res](as)
+func (f *Features[Entity, Req, Res]) AfterCallService(as func(dto Res) error, priority ...int) {
+	hook := NewAfterCallService[Entity, Res](as)
+	hook.priority = priorityOf(priority)
+	f.afterCallService = insertHook(f.afterCallService, hook)
 }
 
-func (f *Features[Entity, Req, Res]) BeforeCallRepo(br func(dto Req, entity Entity) error) {
-	f.beforeCallRepo = NewBeforeCallRepo[Entity, Req, Res](br)
+func (f *Features[Entity, Req, Res]) AroundCallService(as func(ctx *fiber.Ctx, next func() error) error, priority ...int) {
+	hook := NewAroundCallService[Entity, Req](as)
+	hook.priority = priorityOf(priority)
+	f.aroundCallService = insertHook(f.aroundCallService, hook)
 }
 
-func (f *Features[Entity, Req, Res]) AfterCallRepo(ar func(dto Res, entity Entity) error) {
-	f.afterCallRepo = NewAfterCallRepo[Entity, Res](ar)
+func (f *Features[Entity, Req, Res]) BeforeCallRepo(br func(repo Repository[Entity], dto Req, entity Entity) error, priority ...int) {
+	hook := NewBeforeCallRepo[Entity, Req, Res](br)
+	hook.priority = priorityOf(priority)
+	f.beforeCallRepo = insertHook(f.beforeCallRepo, hook)
+}
+
+func (f *Features[Entity, Req, Res]) AfterCallRepo(ar func(repo Repository[Entity], dto Res, entity Entity) error, priority ...int) {
+	hook := NewAfterCallRepo[Entity, Res](ar)
+	hook.priority = priorityOf(priority)
+	f.afterCallRepo = insertHook(f.afterCallRepo, hook)
+}
+
+func (f *Features[Entity, Req, Res]) AroundCallRepo(ar func(repo Repository[Entity], entity Entity, next func() error) error, priority ...int) {
+	hook := NewAroundCallRepo[Entity](ar)
+	hook.priority = priorityOf(priority)
+	f.aroundCallRepo = insertHook(f.aroundCallRepo, hook)
+}
+
+type prioritized interface {
+	Priority() int
+}
+
+func priorityOf(priority []int) int {
+	if len(priority) == 0 {
+		return 0
+	}
+
+	return priority[0]
+}
+
+func insertHook[H prioritized](chain []H, hook H) []H {
+	i := sort.Search(len(chain), func(i int) bool {
+		return chain[i].Priority() > hook.Priority()
+	})
+
+	chain = append(chain, hook)
+	copy(chain[i+1:], chain[i:])
+	chain[i] = hook
+
+	return chain
 }
 
 type HasMethodEvent[Entity interface{}, Req RequestDTO[*Entity], Res ResponseDTO[Entity]] struct {
@@ -94,9 +150,7 @@ func (e *HasMethodEvent[Entity, Req, Res]) getMethodEvent(ev MethodEvent) *Featu
 	}).First()
 
 	if first == nil {
-		f := &Features[Entity, Req, Res]{}
-		e.methodEvent.Add(f)
-		return f
+		return &Features[Entity, Req, Res]{methodEvent: ev}
 	}
 
 	return *first
@@ -118,6 +172,12 @@ func (e *HasMethodEvent[Entity, Req, Res]) setMethodEvent(ev MethodEvent) {
 	}
 }
 
+// Common registers hooks that apply to every method.
+func (e *HasMethodEvent[Entity, Req, Res]) Common() *Features[Entity, Req, Res] {
+	e.setMethodEvent(Common)
+	return e.getMethodEvent(Common)
+}
+
 func (e *HasMethodEvent[Entity, Req, Res]) Create() *Features[Entity, Req, Res] {
 	e.setMethodEvent(Create)
 	return e.getMethodEvent(Create)
@@ -144,11 +204,18 @@ func (e *HasMethodEvent[Entity, Req, Res]) Delete() *Features[Entity, Req, Res]
 	e.setMethodEvent(Delete)
 	return e.getMethodEvent(Delete)
 }
+func (e *HasMethodEvent[Entity, Req, Res]) Restore() *Features[Entity, Req, Res] {
+	e.setMethodEvent(Restore)
+	return e.getMethodEvent(Restore)
+}
 
 type HasHandlerEvent[Entity interface{}, Req RequestDTO[*Entity], Res ResponseDTO[Entity]] struct {
 	*HasMethodEvent[Entity, Req, Res]
 }
 
+func (he *HasHandlerEvent[Entity, Req, Res]) Common() HandlerEvents[Entity, Req, Res] {
+	return he.HasMethodEvent.Common()
+}
 func (he *HasHandlerEvent[Entity, Req, Res]) Create() HandlerEvents[Entity, Req, Res] {
 	return he.HasMethodEvent.Create()
 }
@@ -167,11 +234,17 @@ func (he *HasHandlerEvent[Entity, Req, Res]) Find() HandlerEvents[Entity, Req, R
 func (he *HasHandlerEvent[Entity, Req, Res]) All() HandlerEvents[Entity, Req, Res] {
 	return he.HasMethodEvent.All()
 }
+func (he *HasHandlerEvent[Entity, Req, Res]) Restore() HandlerEvents[Entity, Req, Res] {
+	return he.HasMethodEvent.Restore()
+}
 
 type HasServiceEvent[Entity interface{}, Req RequestDTO[*Entity], Res ResponseDTO[Entity]] struct {
 	*HasMethodEvent[Entity, Req, Res]
 }
 
+func (he *HasServiceEvent[Entity, Req, Res]) Common() ServiceEvents[Entity, Req, Res] {
+	return he.HasMethodEvent.Common()
+}
 func (he *HasServiceEvent[Entity, Req, Res]) Create() ServiceEvents[Entity, Req, Res] {
 	return he.HasMethodEvent.Create()
 }
@@ -190,16 +263,24 @@ func (he *HasServiceEvent[Entity, Req, Res]) Find() ServiceEvents[Entity, Req, R
 func (he *HasServiceEvent[Entity, Req, Res]) All() ServiceEvents[Entity, Req, Res] {
 	return he.HasMethodEvent.All()
 }
+func (he *HasServiceEvent[Entity, Req, Res]) Restore() ServiceEvents[Entity, Req, Res] {
+	return he.HasMethodEvent.Restore()
+}
 
 type ParseRequest[Entity interface{}, Req RequestDTO[*Entity]] struct {
-	event   Event
-	handler func(ctx *fiber.Ctx, dto Req) error
+	event    Event
+	handler  func(ctx *fiber.Ctx, dto Req) error
+	priority int
 }
 
 func (pr *ParseRequest[Entity, Req]) Handler() func(ctx *fiber.Ctx, dto Req) error {
 	return pr.handler
 }
 
+func (pr *ParseRequest[Entity, Req]) Priority() int {
+	return pr.priority
+}
+
 func NewParseRequest[Entity interface{}, Req RequestDTO[*Entity]](handler func(ctx *fiber.Ctx, dto Req) error) *ParseRequest[Entity, Req] {
 	return &ParseRequest[Entity, Req]{
 		event:   ParseRequestEvent,
@@ -211,6 +292,7 @@ type BeforeCallService[Entity interface{}, Req RequestDTO[*Entity], Res Response
 	event   Event
 	handler func(dto Req) error
 	*HasMethodEvent[Entity, Req, Res]
+	priority int
 }
 
 func NewBeforeCallService[Entity interface{}, Req RequestDTO[*Entity], Res ResponseDTO[Entity]](bs func(dto Req) error) *BeforeCallService[Entity, Req, Res] {
@@ -223,9 +305,14 @@ func (bs *BeforeCallService[Entity, Req, Res]) Handler() func(dto Req) error {
 	return bs.handler
 }
 
+func (bs *BeforeCallService[Entity, Req, Res]) Priority() int {
+	return bs.priority
+}
+
 type AfterCallService[Entity interface{}, Res ResponseDTO[Entity]] struct {
-	event   Event
-	handler func(dto Res) error
+	event    Event
+	handler  func(dto Res) error
+	priority int
 }
 
 func NewAfterCallService[Entity interface{}, Res ResponseDTO[Entity]](as func(dto Res) error) *AfterCallService[Entity, Res] {
@@ -238,33 +325,89 @@ func (as *AfterCallService[Entity, Res]) Handler() func(dto Res) error {
 	return as.handler
 }
 
+func (as *AfterCallService[Entity, Res]) Priority() int {
+	return as.priority
+}
+
 type BeforeCallRepo[Entity interface{}, Req RequestDTO[*Entity], Res ResponseDTO[Entity]] struct {
-	event   Event
-	handler func(dto Req, entity Entity) error
+	event    Event
+	handler  func(repo Repository[Entity], dto Req, entity Entity) error
+	priority int
 }
 
-func NewBeforeCallRepo[Entity interface{}, Req RequestDTO[*Entity], Res ResponseDTO[Entity]](br func(dto Req, entity Entity) error) *BeforeCallRepo[Entity, Req, Res] {
+func NewBeforeCallRepo[Entity interface{}, Req RequestDTO[*Entity], Res ResponseDTO[Entity]](br func(repo Repository[Entity], dto Req, entity Entity) error) *BeforeCallRepo[Entity, Req, Res] {
 	return &BeforeCallRepo[Entity, Req, Res]{
 		event:   BeforeCallRepoEvent,
 		handler: br,
 	}
 }
-func (br *BeforeCallRepo[Entity, Req, Res]) Handler() func(dto Req, entity Entity) error {
+func (br *BeforeCallRepo[Entity, Req, Res]) Handler() func(repo Repository[Entity], dto Req, entity Entity) error {
 	return br.handler
 }
 
+func (br *BeforeCallRepo[Entity, Req, Res]) Priority() int {
+	return br.priority
+}
+
 type AfterCallRepo[Entity interface{}, Res ResponseDTO[Entity]] struct {
-	event   Event
-	handler func(dto Res, entity Entity) error
+	event    Event
+	handler  func(repo Repository[Entity], dto Res, entity Entity) error
+	priority int
 }
 
-func NewAfterCallRepo[Entity interface{}, Res ResponseDTO[Entity]](ar func(dto Res, entity Entity) error) *AfterCallRepo[Entity, Res] {
+func NewAfterCallRepo[Entity interface{}, Res ResponseDTO[Entity]](ar func(repo Repository[Entity], dto Res, entity Entity) error) *AfterCallRepo[Entity, Res] {
 	return &AfterCallRepo[Entity, Res]{
 		event:   AfterCallRepoEvent,
 		handler: ar,
 	}
 }
 
-func (ar *AfterCallRepo[Entity, Res]) Handler() func(dto Res, entity Entity) error {
+func (ar *AfterCallRepo[Entity, Res]) Handler() func(repo Repository[Entity], dto Res, entity Entity) error {
+	return ar.handler
+}
+
+func (ar *AfterCallRepo[Entity, Res]) Priority() int {
+	return ar.priority
+}
+
+type AroundCallService[Entity interface{}, Req RequestDTO[*Entity]] struct {
+	event    Event
+	handler  func(ctx *fiber.Ctx, next func() error) error
+	priority int
+}
+
+func NewAroundCallService[Entity interface{}, Req RequestDTO[*Entity]](as func(ctx *fiber.Ctx, next func() error) error) *AroundCallService[Entity, Req] {
+	return &AroundCallService[Entity, Req]{
+		event:   AroundCallServiceEvent,
+		handler: as,
+	}
+}
+
+func (as *AroundCallService[Entity, Req]) Handler() func(ctx *fiber.Ctx, next func() error) error {
+	return as.handler
+}
+
+func (as *AroundCallService[Entity, Req]) Priority() int {
+	return as.priority
+}
+
+type AroundCallRepo[Entity interface{}] struct {
+	event    Event
+	handler  func(repo Repository[Entity], entity Entity, next func() error) error
+	priority int
+}
+
+func NewAroundCallRepo[Entity interface{}](ar func(repo Repository[Entity], entity Entity, next func() error) error) *AroundCallRepo[Entity] {
+	return &AroundCallRepo[Entity]{
+		event:   AroundCallRepoEvent,
+		handler: ar,
+	}
+}
+
+func (ar *AroundCallRepo[Entity]) Handler() func(repo Repository[Entity], entity Entity, next func() error) error {
 	return ar.handler
 }
+
+func (ar *AroundCallRepo[Entity]) Priority() int {
+	return ar.priority
+}
@@ -0,0 +1,51 @@
+package restapi_test
+
+import (
+	"github.com/miniyus/go-restapi"
+	"reflect"
+	"testing"
+)
+
+func TestHook_Chain(t *testing.T) {
+	s := newTestService()
+
+	order := make([]string, 0)
+	record := func(name string) func(repo restapi.Repository[TestEntity], dto *TestReq, entity TestEntity) error {
+		return func(repo restapi.Repository[TestEntity], dto *TestReq, entity TestEntity) error {
+			order = append(order, name)
+			return nil
+		}
+	}
+
+	s.Hook().Find().BeforeCallRepo(record("audit"))
+	s.Hook().Find().BeforeCallRepo(record("auth"), -10)
+	s.Hook().Find().BeforeCallRepo(record("cache"), 10)
+	s.Hook().Find().BeforeCallRepo(record("metrics"))
+	s.Hook().Common().BeforeCallRepo(record("common"), 100)
+
+	s.Hook().Find().AroundCallRepo(func(repo restapi.Repository[TestEntity], entity TestEntity, next func() error) error {
+		order = append(order, "around:inner:before")
+		err := next()
+		order = append(order, "around:inner:after")
+		return err
+	}, 1)
+	s.Hook().Find().AroundCallRepo(func(repo restapi.Repository[TestEntity], entity TestEntity, next func() error) error {
+		order = append(order, "around:outer:before")
+		err := next()
+		order = append(order, "around:outer:after")
+		return err
+	})
+
+	_, err := s.Find(uint(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"common", "auth", "audit", "metrics", "cache",
+		"around:outer:before", "around:inner:before", "around:inner:after", "around:outer:after",
+	}
+	if !reflect.DeepEqual(order, expected) {
+		t.Errorf("expected %v, got %v", expected, order)
+	}
+}
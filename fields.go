@@ -0,0 +1,258 @@
+package restapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+	"reflect"
+	"strings"
+)
+
+// Selectable is implemented by response DTOs that map ?fields= onto columns.
+type Selectable interface {
+	SelectColumns(fields []string) []string
+}
+
+type selectKey struct{}
+
+// WithSelect restricts FindByKey queries run with ctx to columns.
+func WithSelect(ctx context.Context, columns []string) context.Context {
+	return context.WithValue(ctx, selectKey{}, columns)
+}
+
+func selectFrom(ctx context.Context) []string {
+	if ctx == nil {
+		return nil
+	}
+
+	columns, _ := ctx.Value(selectKey{}).([]string)
+	return columns
+}
+
+// ParseFields reads the ?fields=id,name,relation.column syntax.
+func ParseFields(param string, res interface{}) ([]string, error) {
+	if strings.TrimSpace(param) == "" {
+		return nil, nil
+	}
+
+	ty := reflect.TypeOf(res)
+	for ty != nil && ty.Kind() == reflect.Ptr {
+		ty = ty.Elem()
+	}
+
+	seen := make(map[string]bool)
+	fields := make([]string, 0)
+	for _, field := range strings.Split(param, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" || seen[field] {
+			continue
+		}
+
+		if ty == nil {
+			return nil, BadRequest(fmt.Errorf("unknown field '%s'", field))
+		}
+
+		if !hasJSONPath(ty, field) {
+			return nil, BadRequest(fmt.Errorf("unknown field '%s'", field))
+		}
+
+		seen[field] = true
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+func hasJSONPath(ty reflect.Type, path string) bool {
+	for _, seg := range strings.Split(path, ".") {
+		for ty.Kind() == reflect.Ptr || ty.Kind() == reflect.Slice || ty.Kind() == reflect.Array {
+			ty = ty.Elem()
+		}
+
+		if ty.Kind() != reflect.Struct {
+			return false
+		}
+
+		field, ok := jsonField(ty, seg)
+		if !ok {
+			return false
+		}
+		ty = field.Type
+	}
+
+	return true
+}
+
+// PickFields returns the JSON representation of v reduced to fields.
+func PickFields(v interface{}, fields []string) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc interface{}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	if err = decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	tree := make(fieldTree)
+	for _, field := range fields {
+		tree.add(strings.Split(field, "."))
+	}
+
+	return tree.pick(doc), nil
+}
+
+type fieldTree map[string]fieldTree
+
+func (t fieldTree) add(path []string) {
+	child, exists := t[path[0]]
+	if len(path) == 1 {
+		t[path[0]] = nil
+		return
+	}
+
+	if exists && child == nil {
+		return
+	}
+
+	if child == nil {
+		child = make(fieldTree)
+		t[path[0]] = child
+	}
+	child.add(path[1:])
+}
+
+func (t fieldTree) pick(v interface{}) interface{} {
+	switch doc := v.(type) {
+	case map[string]interface{}:
+		picked := make(map[string]interface{}, len(t))
+		for key, child := range t {
+			value, ok := doc[key]
+			if !ok {
+				continue
+			}
+
+			if child == nil {
+				picked[key] = value
+			} else {
+				picked[key] = child.pick(value)
+			}
+		}
+		return picked
+	case []interface{}:
+		picked := make([]interface{}, len(doc))
+		for i, item := range doc {
+			picked[i] = t.pick(item)
+		}
+		return picked
+	}
+
+	return v
+}
+
+func fieldColumns(res interface{}, fields []string, sch *schema.Schema) []string {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	if selectable, ok := res.(Selectable); ok {
+		return selectable.SelectColumns(fields)
+	}
+
+	ty := reflect.TypeOf(res)
+	for ty.Kind() == reflect.Ptr {
+		ty = ty.Elem()
+	}
+
+	seen := make(map[string]bool)
+	columns := make([]string, 0, len(fields))
+	add := func(column string) {
+		if !seen[column] {
+			seen[column] = true
+			columns = append(columns, column)
+		}
+	}
+
+	for _, path := range fields {
+		key := strings.Split(path, ".")[0]
+		candidates := []string{key}
+		if ty.Kind() == reflect.Struct {
+			if field, ok := jsonField(ty, key); ok {
+				candidates = append([]string{field.Name}, candidates...)
+			}
+		}
+
+		resolved := false
+		for _, name := range candidates {
+			if field := sch.LookUpField(name); field != nil && field.DBName != "" {
+				add(field.DBName)
+				resolved = true
+				break
+			}
+
+			if rel, ok := sch.Relationships.Relations[name]; ok {
+				for _, ref := range rel.References {
+					if !ref.OwnPrimaryKey && ref.ForeignKey != nil && ref.ForeignKey.Schema == sch {
+						add(ref.ForeignKey.DBName)
+					}
+				}
+				resolved = true
+				break
+			}
+		}
+
+		if !resolved {
+			return nil
+		}
+	}
+
+	return columns
+}
+
+func selectColumns(db *gorm.DB, sch *schema.Schema, columns []string, extra ...string) *gorm.DB {
+	if len(columns) == 0 {
+		return db
+	}
+
+	seen := make(map[string]bool)
+	vars := make([]interface{}, 0, len(columns)+len(sch.PrimaryFieldDBNames)+len(extra))
+	for _, names := range [][]string{sch.PrimaryFieldDBNames, columns, extra} {
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				vars = append(vars, clause.Column{Table: clause.CurrentTable, Name: name})
+			}
+		}
+	}
+
+	return db.Select(strings.TrimSuffix(strings.Repeat("?,", len(vars)), ","), vars...)
+}
+
+func (f *Filter[Entity]) applySelect(db *gorm.DB) (*gorm.DB, error) {
+	if len(f.Select) == 0 {
+		return db, nil
+	}
+
+	sch, err := parseSchema(db, db.Statement.Model)
+	if err != nil {
+		return db, err
+	}
+
+	extra := make([]string, 0)
+	if f.Cursor != nil {
+		for _, col := range f.Sorter.columns {
+			if field := sch.LookUpField(col.Column); field != nil && field.DBName != "" {
+				extra = append(extra, field.DBName)
+			}
+		}
+	}
+
+	return selectColumns(db, sch, f.Select, extra...), nil
+}
@@ -0,0 +1,68 @@
+package restapi_test
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/miniyus/go-restapi"
+	"github.com/miniyus/gofiber/app"
+	"io"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseFields(t *testing.T) {
+	fields, err := restapi.ParseFields("id, name,test_relation_res.seq", &TestRes{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fields) != 3 || fields[2] != "test_relation_res.seq" {
+		t.Errorf("unexpected fields %v", fields)
+	}
+
+	_, err = restapi.ParseFields("id,password", &TestRes{})
+	if !errors.Is(err, restapi.ErrBadRequest) {
+		t.Errorf("expected bad request, got %v", err)
+	}
+}
+
+func TestPickFields(t *testing.T) {
+	res := &TestRes{Id: 1, Name: "name", TestRelationRes: TestRelationRes{Id: 2, Seq: 3}}
+	picked, err := restapi.PickFields(res, []string{"name", "test_relation_res.seq"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal(picked)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != `{"name":"name","test_relation_res":{"seq":3}}` {
+		t.Errorf("unexpected output %s", b)
+	}
+}
+
+func TestGenericHandler_AllFields(t *testing.T) {
+	app.App().Fiber().Get("/fields-tests", h.All)
+	test, err := app.App().Test(httptest.NewRequest("GET", "/fields-tests?fields=id,name", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := io.ReadAll(test.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var all restapi.Paginated[map[string]interface{}]
+	if err = json.Unmarshal(body, &all); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, item := range all.Data {
+		if len(item) != 2 || item["id"] == nil {
+			t.Errorf("unexpected item %v", item)
+		}
+	}
+}
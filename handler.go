@@ -1,9 +1,11 @@
 package restapi
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"github.com/gofiber/fiber/v2"
-	"github.com/miniyus/gofiber/utils"
-	"strconv"
+	"strings"
 )
 
 type Handler[Entity interface{}, Req RequestDTO[*Entity], Res ResponseDTO[Entity]] interface {
@@ -13,20 +15,39 @@ type Handler[Entity interface{}, Req RequestDTO[*Entity], Res ResponseDTO[Entity
 	Update(ctx *fiber.Ctx) error
 	Patch(ctx *fiber.Ctx) error
 	Delete(ctx *fiber.Ctx) error
+	Restore(ctx *fiber.Ctx) error
+	BulkCreate(ctx *fiber.Ctx) error
+	BulkPatch(ctx *fiber.Ctx) error
+	BulkDelete(ctx *fiber.Ctx) error
 	GetService() Service[Entity, Req, Res]
+	SetKeyParser(parser KeyParser)
+	ParseKey(param string) (interface{}, error)
+	Key(ctx *fiber.Ctx) (interface{}, error)
+	SetIdParam(name string)
+	SetBulkMode(mode BulkMode)
 	HandlerHook[Entity, Req, Res]
 }
 
 type GenericHandler[Entity interface{}, Req RequestDTO[*Entity], Res ResponseDTO[Entity]] struct {
-	req     Req
-	service Service[Entity, Req, Res]
-	events  *HasHandlerEvent[Entity, Req, Res]
+	newReq   DTOFactory[Req]
+	parseKey KeyParser
+	idParam  string
+	bulkMode BulkMode
+	service  Service[Entity, Req, Res]
+	events   *HasHandlerEvent[Entity, Req, Res]
 }
 
 func NewHandler[Entity interface{}, Req RequestDTO[*Entity], Res ResponseDTO[Entity]](req Req, service Service[Entity, Req, Res]) Handler[Entity, Req, Res] {
+	return NewHandlerWithFactory[Entity, Req, Res](NewDTOFactory(req), service)
+}
+
+func NewHandlerWithFactory[Entity interface{}, Req RequestDTO[*Entity], Res ResponseDTO[Entity]](newReq DTOFactory[Req], service Service[Entity, Req, Res]) Handler[Entity, Req, Res] {
+	var ent Entity
 	return &GenericHandler[Entity, Req, Res]{
-		req:     req,
-		service: service,
+		newReq:   newReq,
+		parseKey: KeyParserFor(&ent),
+		idParam:  "id",
+		service:  service,
 		events: &HasHandlerEvent[Entity, Req, Res]{
 			&HasMethodEvent[Entity, Req, Res]{methodEvent: nil},
 		},
@@ -34,203 +55,316 @@ func NewHandler[Entity interface{}, Req RequestDTO[*Entity], Res ResponseDTO[Ent
 }
 
 func (g *GenericHandler[Entity, Req, Res]) All(ctx *fiber.Ctx) error {
-	if g.features(All).parseRequest != nil {
-		err := g.features(All).parseRequest.handler(ctx, g.req)
-		if err != nil {
-			return err
-		}
+	req := g.newReq()
+	err := g.parseRequest(All, ctx, req)
+	if err != nil {
+		return g.error(ctx, err)
+	}
+
+	filter, err := NewFilter[Entity](ctx, req)
+	if err != nil {
+		return g.error(ctx, err)
 	}
 
-	filter, err := NewFilter[Entity](ctx, g.req)
+	fields, err := g.fields(ctx)
+	if err != nil {
+		return g.error(ctx, err)
+	}
+	filter.Select = fields.columns
 
-	if g.features(All).beforeCallService != nil {
-		err = g.features(All).beforeCallService.handler(g.req)
+	err = g.beforeCallService(All, req)
+	if err != nil {
+		return g.error(ctx, err)
+	}
+
+	var all *Paginated[Res]
+	err = g.aroundCallService(All, ctx, func() (err error) {
+		all, err = g.service.AllContext(ctx.UserContext(), filter)
+		return err
+	})
+	if err != nil {
+		return g.error(ctx, err)
+	}
+
+	for _, res := range all.Data {
+		err = g.afterCallService(All, res)
 		if err != nil {
-			return err
+			return g.error(ctx, err)
 		}
 	}
 
-	all, err := g.service.All(filter)
+	if fields.paths == nil {
+		return ctx.Status(fiber.StatusOK).JSON(all)
+	}
 
+	data, err := PickFields(all.Data, fields.paths)
 	if err != nil {
-		return err
+		return g.error(ctx, err)
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(all)
+	return ctx.Status(fiber.StatusOK).JSON(&Paginated[interface{}]{
+		Data:       data.([]interface{}),
+		Total:      all.Total,
+		Page:       all.Page,
+		PageSize:   all.PageSize,
+		TotalPages: all.TotalPages,
+		NextCursor: all.NextCursor,
+		PrevCursor: all.PrevCursor,
+		keyset:     all.keyset,
+	})
 }
 
+// Find sends the version of the record as ETag and answers If-None-Match with 304.
 func (g *GenericHandler[Entity, Req, Res]) Find(ctx *fiber.Ctx) error {
-	if g.features(Find).parseRequest != nil {
-		err := g.features(Find).parseRequest.handler(ctx, g.req)
-		if err != nil {
-			return err
-		}
+	req := g.newReq()
+	err := g.parseRequest(Find, ctx, req)
+	if err != nil {
+		return g.error(ctx, err)
 	}
 
-	params := ctx.AllParams()
-	pk, err := strconv.ParseUint(params["id"], 10, 64)
+	pk, err := g.pk(ctx)
 	if err != nil {
-		return err
+		return g.error(ctx, err)
+	}
+
+	fields, err := g.fields(ctx)
+	if err != nil {
+		return g.error(ctx, err)
 	}
 
-	find, err := g.service.Find(uint(pk))
+	err = g.beforeCallService(Find, req)
 	if err != nil {
+		return g.error(ctx, err)
+	}
+
+	var find Res
+	var version string
+	err = g.aroundCallService(Find, ctx, func() (err error) {
+		uc := withVersion(WithSelect(g.userContext(ctx, req), fields.columns), &version)
+		find, err = g.service.FindContext(uc, pk)
 		return err
+	})
+	if err != nil {
+		return g.error(ctx, err)
 	}
 
-	if g.features(Find).afterCallService != nil {
-		err = g.features(Find).afterCallService.handler(find)
-		if err != nil {
-			return err
+	if version != "" {
+		ctx.Set(fiber.HeaderETag, ETag(version))
+		if MatchETag(ctx.Get(fiber.HeaderIfNoneMatch), version) {
+			return ctx.SendStatus(fiber.StatusNotModified)
 		}
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(find)
+	err = g.afterCallService(Find, find)
+	if err != nil {
+		return g.error(ctx, err)
+	}
+
+	return g.respond(ctx, fiber.StatusOK, find, fields.paths)
 }
 
 func (g *GenericHandler[Entity, Req, Res]) Create(ctx *fiber.Ctx) error {
-	if g.features(Create).parseRequest != nil {
-		err := g.features(Create).parseRequest.handler(ctx, g.req)
-		if err != nil {
-			return err
-		}
+	req := g.newReq()
+	err := g.parseRequest(Create, ctx, req)
+	if err != nil {
+		return g.error(ctx, err)
 	}
 
-	req := g.req
-	errRes := utils.HandleValidate(ctx, req)
-	if errRes != nil {
-		return errRes.Response()
+	err = ValidateStruct(ctx, req)
+	if err != nil {
+		return g.error(ctx, err)
 	}
 
-	if g.features(Create).beforeCallService != nil {
-		err := g.features(Create).beforeCallService.handler(req)
-		if err != nil {
-			return err
-		}
+	err = g.beforeCallService(Create, req)
+	if err != nil {
+		return g.error(ctx, err)
 	}
 
-	create, err := g.service.Create(req)
-	if err != nil {
+	var create Res
+	err = g.aroundCallService(Create, ctx, func() (err error) {
+		create, err = g.service.CreateContext(ctx.UserContext(), req)
 		return err
+	})
+	if err != nil {
+		return g.error(ctx, err)
 	}
 
-	if g.features(Create).afterCallService != nil {
-		err = g.features(Create).afterCallService.handler(create)
-		if err != nil {
-			return err
-		}
+	err = g.afterCallService(Create, create)
+	if err != nil {
+		return g.error(ctx, err)
 	}
-	return ctx.Status(fiber.StatusCreated).JSON(create)
+	return g.respond(ctx, fiber.StatusCreated, create, g.readable(ctx))
 }
 
 func (g *GenericHandler[Entity, Req, Res]) Update(ctx *fiber.Ctx) error {
-	if g.features(Update).parseRequest != nil {
-		err := g.features(Update).parseRequest.handler(ctx, g.req)
-		if err != nil {
-			return err
-		}
+	req := g.newReq()
+	err := g.parseRequest(Update, ctx, req)
+	if err != nil {
+		return g.error(ctx, err)
 	}
 
-	params := ctx.AllParams()
-	pk, err := strconv.ParseUint(params["id"], 10, 64)
+	pk, err := g.pk(ctx)
 	if err != nil {
-		return err
+		return g.error(ctx, err)
 	}
 
-	req := g.req
-	errRes := utils.HandleValidate(ctx, req)
-	if errRes != nil {
-		return errRes.Response()
+	err = ValidateStruct(ctx, req)
+	if err != nil {
+		return g.error(ctx, err)
 	}
 
-	if g.features(Update).beforeCallService != nil {
-		err = g.features(Update).beforeCallService.handler(req)
-		if err != nil {
-			return err
-		}
+	err = g.beforeCallService(Update, req)
+	if err != nil {
+		return g.error(ctx, err)
 	}
 
-	update, err := g.service.Update(uint(pk), req)
-	if err != nil {
+	var update Res
+	var version string
+	err = g.aroundCallService(Update, ctx, func() (err error) {
+		update, err = g.service.UpdateContext(withVersion(g.userContext(ctx, req), &version), pk, req)
 		return err
+	})
+	if err != nil {
+		return g.error(ctx, err)
 	}
 
-	if g.features(Update).afterCallService != nil {
-		err = g.features(Update).afterCallService.handler(update)
-		if err != nil {
-			return err
-		}
+	err = g.afterCallService(Update, update)
+	if err != nil {
+		return g.error(ctx, err)
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(update)
+	if version != "" {
+		ctx.Set(fiber.HeaderETag, ETag(version))
+	}
+
+	return g.respond(ctx, fiber.StatusOK, update, g.readable(ctx))
 }
 
 func (g *GenericHandler[Entity, Req, Res]) Patch(ctx *fiber.Ctx) error {
-	if g.features(Patch).parseRequest != nil {
-		err := g.features(Patch).parseRequest.handler(ctx, g.req)
-		if err != nil {
-			return err
-		}
+	req := g.newReq()
+	err := g.parseRequest(Patch, ctx, req)
+	if err != nil {
+		return g.error(ctx, err)
 	}
 
-	params := ctx.AllParams()
-	pk, err := strconv.ParseUint(params["id"], 10, 64)
+	pk, err := g.pk(ctx)
 	if err != nil {
-		return err
+		return g.error(ctx, err)
 	}
 
-	req := g.req
-	errRes := utils.HandleValidate(ctx, req)
-	if errRes != nil {
-		return errRes.Response()
+	fields, err := ParsePatch(ctx, req)
+	if err != nil {
+		return g.error(ctx, err)
 	}
 
-	if g.features(Patch).beforeCallService != nil {
-		err = g.features(Patch).beforeCallService.handler(req)
-		if err != nil {
-			return err
-		}
+	err = ValidatePartial(ctx, req, fields)
+	if err != nil {
+		return g.error(ctx, err)
 	}
 
-	update, err := g.service.Patch(uint(pk), req)
+	err = g.beforeCallService(Patch, req)
 	if err != nil {
+		return g.error(ctx, err)
+	}
+
+	var update Res
+	var version string
+	err = g.aroundCallService(Patch, ctx, func() (err error) {
+		update, err = g.service.PatchContext(withVersion(g.userContext(ctx, req), &version), pk, req, fields...)
 		return err
+	})
+	if err != nil {
+		return g.error(ctx, err)
 	}
 
-	if g.features(Patch).afterCallService != nil {
-		err = g.features(Patch).afterCallService.handler(update)
-		if err != nil {
-			return err
-		}
+	err = g.afterCallService(Patch, update)
+	if err != nil {
+		return g.error(ctx, err)
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(update)
+	if version != "" {
+		ctx.Set(fiber.HeaderETag, ETag(version))
+	}
+
+	return g.respond(ctx, fiber.StatusOK, update, g.readable(ctx))
 }
 
+// Delete soft deletes the record, or deletes it permanently with ?force=true.
 func (g *GenericHandler[Entity, Req, Res]) Delete(ctx *fiber.Ctx) error {
-	if g.features(Delete).parseRequest != nil {
-		err := g.features(Delete).parseRequest.handler(ctx, g.req)
-		if err != nil {
-			return err
-		}
+	req := g.newReq()
+	err := g.parseRequest(Delete, ctx, req)
+	if err != nil {
+		return g.error(ctx, err)
 	}
 
-	params := ctx.AllParams()
-	pk, err := strconv.ParseUint(params["id"], 10, 64)
+	pk, err := g.pk(ctx)
 	if err != nil {
-		return err
+		return g.error(ctx, err)
 	}
 
-	find, err := g.service.Delete(uint(pk))
+	err = g.beforeCallService(Delete, req)
 	if err != nil {
+		return g.error(ctx, err)
+	}
+
+	// after-service hooks receive the record as it was before deletion
+	var deleted Res
+	err = g.aroundCallService(Delete, ctx, func() (err error) {
+		if ctx.QueryBool("force") {
+			deleted, err = g.service.ForceDeleteContext(g.userContext(ctx, req), pk)
+		} else {
+			deleted, err = g.service.DeleteContext(g.userContext(ctx, req), pk)
+		}
 		return err
+	})
+	if err != nil {
+		return g.error(ctx, err)
+	}
+
+	err = g.afterCallService(Delete, deleted)
+	if err != nil {
+		return g.error(ctx, err)
 	}
 
 	return ctx.Status(fiber.StatusNoContent).JSON(map[string]interface{}{
-		"result": find,
+		"result": true,
 	})
 }
 
+func (g *GenericHandler[Entity, Req, Res]) Restore(ctx *fiber.Ctx) error {
+	req := g.newReq()
+	err := g.parseRequest(Restore, ctx, req)
+	if err != nil {
+		return g.error(ctx, err)
+	}
+
+	pk, err := g.pk(ctx)
+	if err != nil {
+		return g.error(ctx, err)
+	}
+
+	err = g.beforeCallService(Restore, req)
+	if err != nil {
+		return g.error(ctx, err)
+	}
+
+	var restore Res
+	err = g.aroundCallService(Restore, ctx, func() (err error) {
+		restore, err = g.service.RestoreContext(g.userContext(ctx, req), pk)
+		return err
+	})
+	if err != nil {
+		return g.error(ctx, err)
+	}
+
+	err = g.afterCallService(Restore, restore)
+	if err != nil {
+		return g.error(ctx, err)
+	}
+
+	return g.respond(ctx, fiber.StatusOK, restore, g.readable(ctx))
+}
+
 func (g *GenericHandler[Entity, Req, Res]) GetService() Service[Entity, Req, Res] {
 	return g.service
 }
@@ -239,6 +373,165 @@ func (g *GenericHandler[Entity, Req, Res]) Hook() *HasHandlerEvent[Entity, Req,
 	return g.events
 }
 
+func (g *GenericHandler[Entity, Req, Res]) SetKeyParser(parser KeyParser) {
+	g.parseKey = parser
+}
+
+// ParseKey converts a path parameter into a key with the handler's KeyParser.
+func (g *GenericHandler[Entity, Req, Res]) ParseKey(param string) (interface{}, error) {
+	return g.parseKey(param)
+}
+
+// SetIdParam renames the path parameter holding the key, "id" by default.
+func (g *GenericHandler[Entity, Req, Res]) SetIdParam(name string) {
+	g.idParam = name
+}
+
+// Key parses the key of the record addressed by ctx.
+func (g *GenericHandler[Entity, Req, Res]) Key(ctx *fiber.Ctx) (interface{}, error) {
+	return g.pk(ctx)
+}
+
+func (g *GenericHandler[Entity, Req, Res]) pk(ctx *fiber.Ctx) (interface{}, error) {
+	name, ok := ctx.Locals(idParamKey{}).(string)
+	if !ok {
+		name = g.idParam
+	}
+
+	return g.parseKey(ctx.Params(name))
+}
+
+func (g *GenericHandler[Entity, Req, Res]) readable(ctx *fiber.Ctx) []string {
+	if fields, ok := g.service.Policy().(FieldPolicy); ok {
+		return fields.ReadableFields(ctx.UserContext())
+	}
+
+	return nil
+}
+
+func (g *GenericHandler[Entity, Req, Res]) respond(ctx *fiber.Ctx, status int, res interface{}, paths []string) error {
+	if paths == nil {
+		return ctx.Status(status).JSON(res)
+	}
+
+	picked, err := PickFields(res, paths)
+	if err != nil {
+		return g.error(ctx, err)
+	}
+
+	return ctx.Status(status).JSON(picked)
+}
+
+type fieldset struct {
+	paths   []string
+	columns []string
+}
+
+func (g *GenericHandler[Entity, Req, Res]) fields(ctx *fiber.Ctx) (fieldset, error) {
+	res := g.service.Response()
+	paths, err := ParseFields(ctx.Query("fields"), res)
+	if err != nil {
+		return fieldset{}, err
+	}
+
+	readable := g.readable(ctx)
+	if paths == nil {
+		return fieldset{paths: readable}, nil
+	}
+
+	if readable != nil {
+		for _, path := range paths {
+			if !containsField(readable, strings.Split(path, ".")[0]) {
+				return fieldset{}, Forbidden(fmt.Errorf("field '%s' is not readable", path))
+			}
+		}
+	}
+
+	var ent Entity
+	sch, err := parseSchema(g.service.Repo().DB(), &ent)
+	if err != nil {
+		return fieldset{}, err
+	}
+
+	return fieldset{paths: paths, columns: fieldColumns(res, paths, sch)}, nil
+}
+
+func (g *GenericHandler[Entity, Req, Res]) userContext(ctx *fiber.Ctx, req Req) context.Context {
+	uc := WithInclude(ctx.UserContext(), NewIncluder(ctx.Query("include"), req))
+	if ifMatch := ctx.Get(fiber.HeaderIfMatch); ifMatch != "" {
+		uc = WithIfMatch(uc, ifMatch)
+	}
+
+	return uc
+}
+
+func (g *GenericHandler[Entity, Req, Res]) error(ctx *fiber.Ctx, err error) error {
+	if errors.Is(err, errResponded) {
+		return nil
+	}
+
+	return ErrorHandler(ctx, err)
+}
+
+func (g *GenericHandler[Entity, Req, Res]) parseRequest(event MethodEvent, ctx *fiber.Ctx, dto Req) error {
+	for _, ev := range []MethodEvent{Common, event} {
+		for _, hook := range g.features(ev).parseRequest {
+			if err := hook.handler(ctx, dto); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (g *GenericHandler[Entity, Req, Res]) beforeCallService(event MethodEvent, dto Req) error {
+	for _, ev := range []MethodEvent{Common, event} {
+		for _, hook := range g.features(ev).beforeCallService {
+			if err := hook.handler(dto); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (g *GenericHandler[Entity, Req, Res]) afterCallService(event MethodEvent, res Res) error {
+	for _, ev := range []MethodEvent{Common, event} {
+		for _, hook := range g.features(ev).afterCallService {
+			if err := hook.handler(res); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (g *GenericHandler[Entity, Req, Res]) aroundCallService(event MethodEvent, ctx *fiber.Ctx, call func() error) error {
+	hooks := append(append([]*AroundCallService[Entity, Req]{}, g.features(Common).aroundCallService...), g.features(event).aroundCallService...)
+	called := false
+	next := func() error {
+		called = true
+		return call()
+	}
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hook, inner := hooks[i], next
+		next = func() error {
+			return hook.handler(ctx, inner)
+		}
+	}
+
+	err := next()
+	if err == nil && !called {
+		return errResponded
+	}
+
+	return err
+}
+
 func (g *GenericHandler[Entity, Req, Res]) features(event MethodEvent) *Features[Entity, Req, Res] {
 	return g.events.getMethodEvent(event)
 }
@@ -2,7 +2,9 @@ package restapi_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"github.com/gofiber/fiber/v2"
 	"github.com/miniyus/go-restapi"
 	"github.com/miniyus/gofiber/app"
 	"io"
@@ -67,6 +69,80 @@ func TestGenericHandler_All(t *testing.T) {
 	t.Log(string(all))
 }
 
+func TestGenericHandler_AllPageSize(t *testing.T) {
+	app.App().Fiber().Get("/tests", h.All)
+	testCases := map[string]int{
+		"/tests?page_size=0":         restapi.DefaultPageSize,
+		"/tests?page_size=-1":        restapi.DefaultPageSize,
+		"/tests?page_size=100000000": restapi.MaxPageSize,
+	}
+
+	for url, size := range testCases {
+		test, err := app.App().Test(httptest.NewRequest("GET", url, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		page := restapi.Paginated[TestRes]{}
+		if err = json.NewDecoder(test.Body).Decode(&page); err != nil {
+			t.Fatal(err)
+		}
+
+		if page.PageSize != size || len(page.Data) > size {
+			t.Errorf("%s: expected pages of %d, got %d with %d rows", url, size, page.PageSize, len(page.Data))
+		}
+	}
+}
+
+func TestGenericHandler_AllConditions(t *testing.T) {
+	app.App().Fiber().Get("/tests", h.All)
+	testCases := map[string]int{
+		"/tests?id[gte]=2&name[like]=%25a%25": fiber.StatusOK,
+		"/tests?id[in]=1,2,3":                 fiber.StatusOK,
+		"/tests?deleted_at[null]=true":        fiber.StatusOK,
+		"/tests?seq[eq]=1":                    fiber.StatusBadRequest,
+		"/tests?id[gte]=abc":                  fiber.StatusBadRequest,
+		"/tests?id[between]=1":                fiber.StatusBadRequest,
+	}
+
+	for url, status := range testCases {
+		req := httptest.NewRequest("GET", url, nil)
+		test, err := app.App().Test(req)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if test.StatusCode != status {
+			t.Errorf("%s: expected status %d, got %d", url, status, test.StatusCode)
+		}
+	}
+}
+
+func TestGenericHandler_AllSort(t *testing.T) {
+	app.App().Fiber().Get("/tests", h.All)
+	testCases := map[string]int{
+		"/tests?sort=-created_at,name":           fiber.StatusOK,
+		"/tests?sort=-test_relation_model.seq":   fiber.StatusOK,
+		"/tests?sort=name%20desc":                fiber.StatusBadRequest,
+		"/tests?sort=id;DROP%20TABLE%20x":        fiber.StatusBadRequest,
+		"/tests?sort=unknown_relation.seq,-name": fiber.StatusBadRequest,
+	}
+
+	for url, status := range testCases {
+		req := httptest.NewRequest("GET", url, nil)
+		test, err := app.App().Test(req)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if test.StatusCode != status {
+			t.Errorf("%s: expected status %d, got %d", url, status, test.StatusCode)
+		}
+	}
+}
+
 func TestGenericHandler_Find(t *testing.T) {
 	app.App().Fiber().Get("/tests/:id", h.Find)
 	req := httptest.NewRequest("GET", "/tests/1", nil)
@@ -81,6 +157,39 @@ func TestGenericHandler_Find(t *testing.T) {
 	t.Log(string(all))
 }
 
+func TestGenericHandler_Patch(t *testing.T) {
+	app.App().Fiber().Patch("/tests/:id", h.Patch)
+	include := restapi.WithInclude(context.Background(), restapi.NewIncluder("test_relation_model", nil))
+	before, err := h.GetService().FindContext(include, uint(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("PATCH", "/tests/1", bytes.NewReader([]byte(`{"name":"patched-name"}`)))
+	req.Header.Set("Content-Type", restapi.MergePatchContentType)
+	test, err := app.App().Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if test.StatusCode != fiber.StatusOK {
+		t.Fatalf("unexpected status %d", test.StatusCode)
+	}
+
+	after, err := h.GetService().FindContext(include, uint(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if after.Name != "patched-name" {
+		t.Errorf("name not patched: %s", after.Name)
+	}
+
+	if after.TestRelationRes.Seq != before.TestRelationRes.Seq {
+		t.Errorf("absent field changed: %d -> %d", before.TestRelationRes.Seq, after.TestRelationRes.Seq)
+	}
+}
+
 func TestGenericHandler_Hook(t *testing.T) {
 	hook := h.Hook()
 	find := hook.Find()
@@ -103,3 +212,145 @@ func TestGenericHandler_Hook(t *testing.T) {
 	}
 	t.Log(string(all))
 }
+
+func TestGenericHandler_FindError(t *testing.T) {
+	app.App().Fiber().Get("/tests/:id", h.Find)
+	testCases := map[string]int{
+		"/tests/999999": fiber.StatusNotFound,
+		"/tests/abc":    fiber.StatusBadRequest,
+	}
+
+	for url, status := range testCases {
+		req := httptest.NewRequest("GET", url, nil)
+		test, err := app.App().Test(req)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if test.StatusCode != status {
+			t.Errorf("%s: expected status %d, got %d", url, status, test.StatusCode)
+		}
+
+		if ct := test.Header.Get("Content-Type"); ct != restapi.ProblemContentType {
+			t.Errorf("%s: unexpected content type %s", url, ct)
+		}
+	}
+}
+
+func TestGenericHandler_CommonHook(t *testing.T) {
+	handler := newTestHandler()
+
+	order := make([]string, 0)
+	handler.Hook().Find().AfterCallService(func(dto *TestRes) error {
+		order = append(order, "find")
+		return nil
+	})
+	handler.Hook().Common().AfterCallService(func(dto *TestRes) error {
+		order = append(order, "common")
+		return nil
+	})
+
+	app.App().Fiber().Get("/common-hook/:id", handler.Find)
+	req := httptest.NewRequest("GET", "/common-hook/1", nil)
+	_, err := app.App().Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(order) != 2 || order[0] != "common" || order[1] != "find" {
+		t.Errorf("unexpected hook order: %v", order)
+	}
+}
+
+func TestGenericHandler_BulkCreate(t *testing.T) {
+	handler := newTestHandler()
+	app.App().Fiber().Post("/bulk-tests/bulk", handler.BulkCreate)
+
+	items := make([]interface{}, 0)
+	for _, data := range makeFakeData(2) {
+		items = append(items, data)
+	}
+	items = append(items, map[string]interface{}{"test_relation": map[string]int{"seq": 1}})
+	body, err := json.Marshal(items)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		mode      restapi.BulkMode
+		status    int
+		committed bool
+	}{
+		{restapi.AllOrNothing, fiber.StatusUnprocessableEntity, false},
+		{restapi.PartialSuccess, fiber.StatusMultiStatus, true},
+	}
+
+	for _, tc := range testCases {
+		handler.SetBulkMode(tc.mode)
+		req := httptest.NewRequest("POST", "/bulk-tests/bulk", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		test, err := app.App().Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if test.StatusCode != tc.status {
+			t.Errorf("mode %d: expected status %d, got %d", tc.mode, tc.status, test.StatusCode)
+		}
+
+		var result restapi.BulkResult[*TestRes]
+		if err = json.NewDecoder(test.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+
+		if result.Committed != tc.committed || result.Failed != 1 || result.Items[2].Status != fiber.StatusUnprocessableEntity {
+			t.Errorf("mode %d: unexpected result %+v", tc.mode, result)
+		}
+	}
+}
+
+func TestGenericHandler_BulkAfterHook(t *testing.T) {
+	handler := newTestHandler()
+	handler.Hook().Create().AfterCallService(func(dto *TestRes) error {
+		return restapi.Forbidden(nil)
+	})
+	app.App().Fiber().Post("/bulk-hook-tests/bulk", handler.BulkCreate)
+
+	body, err := json.Marshal(makeFakeData(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("POST", "/bulk-hook-tests/bulk", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	test, err := app.App().Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result restapi.BulkResult[*TestRes]
+	if err = json.NewDecoder(test.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+
+	if test.StatusCode != fiber.StatusMultiStatus || !result.Committed || result.Items[0].Status != fiber.StatusForbidden {
+		t.Errorf("expected the hook failure on the committed item, got %d %+v", test.StatusCode, result)
+	}
+}
+
+func TestGenericHandler_BulkDeleteUnknownFilter(t *testing.T) {
+	app.App().Fiber().Delete("/bulk-delete-tests/bulk", h.BulkDelete)
+
+	for _, query := range []string{"", "?force=1", "?nmae=test"} {
+		req := httptest.NewRequest("DELETE", "/bulk-delete-tests/bulk"+query, nil)
+		test, err := app.App().Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if test.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", query, fiber.StatusBadRequest, test.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package restapi
+
+import (
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+	"strings"
+)
+
+// Includable is implemented by request DTOs that restrict ?include=.
+type Includable interface {
+	IncludeRelations() []string
+}
+
+// Includer preloads the relations named by ?include=relation,relation.child.
+type Includer struct {
+	paths   []string
+	allowed []string
+}
+
+// NewIncluder parses ?include= and takes the whitelist from req when it is Includable.
+func NewIncluder(param string, req interface{}) Includer {
+	paths := make([]string, 0)
+	for _, path := range strings.Split(param, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, path)
+		}
+	}
+
+	includer := Includer{paths: paths}
+	if includable, ok := req.(Includable); ok {
+		includer.allowed = includable.IncludeRelations()
+	}
+
+	return includer
+}
+
+func (i Includer) Include(db *gorm.DB) (*gorm.DB, error) {
+	if len(i.paths) == 0 {
+		return db.Preload(clause.Associations), nil
+	}
+
+	sch, err := parseSchema(db, db.Statement.Model)
+	if err != nil {
+		return db, err
+	}
+
+	for _, path := range i.paths {
+		if !i.isAllowed(path) {
+			return db, BadRequest(fmt.Errorf("include of '%s' is not allowed", path))
+		}
+
+		name, err := resolveInclude(db, sch, path)
+		if err != nil {
+			return db, err
+		}
+
+		db = db.Preload(name)
+	}
+
+	return db, nil
+}
+
+func (i Includer) isAllowed(path string) bool {
+	if i.allowed == nil {
+		return true
+	}
+
+	for _, allowed := range i.allowed {
+		if allowed == path || strings.HasPrefix(allowed, path+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func resolveInclude(db *gorm.DB, sch *schema.Schema, path string) (string, error) {
+	names := make([]string, 0)
+	for _, seg := range strings.Split(path, ".") {
+		rel := lookUpRelation(db, sch, seg)
+		if rel == nil {
+			return "", BadRequest(fmt.Errorf("unknown relation '%s'", path))
+		}
+
+		names = append(names, rel.Name)
+		sch = rel.FieldSchema
+	}
+
+	return strings.Join(names, "."), nil
+}
+
+type includeKey struct{}
+
+// WithInclude makes FindByKey queries run with ctx preload the relations of includer.
+func WithInclude(ctx context.Context, includer Includer) context.Context {
+	return context.WithValue(ctx, includeKey{}, includer)
+}
+
+func includeFrom(ctx context.Context) Includer {
+	if ctx == nil {
+		return Includer{}
+	}
+
+	includer, _ := ctx.Value(includeKey{}).(Includer)
+	return includer
+}
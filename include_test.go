@@ -0,0 +1,43 @@
+package restapi_test
+
+import (
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/miniyus/gofiber/app"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGenericHandler_FindInclude(t *testing.T) {
+	app.App().Fiber().Get("/include-tests/:id", h.Find)
+
+	testCases := map[string]bool{
+		"/include-tests/1": true,
+		"/include-tests/1?include=test_relation_model": true,
+	}
+
+	for url, loaded := range testCases {
+		test, err := app.App().Test(httptest.NewRequest("GET", url, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var res TestRes
+		if err = json.NewDecoder(test.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+
+		if (res.TestRelationRes.Id != 0) != loaded {
+			t.Errorf("%s: expected relation loaded %v, got %+v", url, loaded, res.TestRelationRes)
+		}
+	}
+
+	test, err := app.App().Test(httptest.NewRequest("GET", "/include-tests/1?include=unknown", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if test.StatusCode != fiber.StatusBadRequest {
+		t.Errorf("expected 400 for unknown relation, got %d", test.StatusCode)
+	}
+}
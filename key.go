@@ -0,0 +1,160 @@
+package restapi
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// KeyParser converts the :id route parameter into a primary key value.
+type KeyParser func(param string) (interface{}, error)
+
+const CompositeKeySeparator = ","
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+func UintKey(param string) (interface{}, error) {
+	pk, err := strconv.ParseUint(param, 10, 64)
+	if err != nil {
+		return nil, BadRequest(fmt.Errorf("invalid id '%s'", param))
+	}
+
+	return uint(pk), nil
+}
+
+func StringKey(param string) (interface{}, error) {
+	if param == "" {
+		return nil, BadRequest(errors.New("empty id"))
+	}
+
+	return param, nil
+}
+
+func UUIDKey(param string) (interface{}, error) {
+	if !uuidPattern.MatchString(param) {
+		return nil, BadRequest(fmt.Errorf("invalid uuid '%s'", param))
+	}
+
+	return strings.ToLower(param), nil
+}
+
+// KeyParserFor derives a KeyParser from the primary key fields of model.
+func KeyParserFor(model interface{}) KeyParser {
+	sch, err := parseModel(model)
+	if err != nil || len(sch.PrimaryFields) == 0 {
+		return UintKey
+	}
+
+	if len(sch.PrimaryFields) == 1 {
+		return fieldKeyParser(sch.PrimaryFields[0])
+	}
+
+	fields := sch.PrimaryFields
+	return func(param string) (interface{}, error) {
+		parts := strings.Split(param, CompositeKeySeparator)
+		if len(parts) != len(fields) {
+			return nil, BadRequest(fmt.Errorf("id must have %d parts", len(fields)))
+		}
+
+		key := make(map[string]interface{}, len(fields))
+		for i, field := range fields {
+			v, err := fieldKeyParser(field)(parts[i])
+			if err != nil {
+				return nil, err
+			}
+			key[field.Name] = v
+		}
+
+		return key, nil
+	}
+}
+
+func fieldKeyParser(field *schema.Field) KeyParser {
+	ty := field.FieldType
+	if _, ok := reflect.New(ty).Interface().(encoding.TextUnmarshaler); ok {
+		return func(param string) (interface{}, error) {
+			ptr := reflect.New(ty)
+			err := ptr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(param))
+			if err != nil {
+				return nil, BadRequest(fmt.Errorf("invalid id '%s'", param))
+			}
+
+			return ptr.Elem().Interface(), nil
+		}
+	}
+
+	if ty.Kind() == reflect.String {
+		if strings.EqualFold(string(field.DataType), "uuid") || strings.EqualFold(field.TagSettings["TYPE"], "uuid") {
+			return UUIDKey
+		}
+		return StringKey
+	}
+
+	return func(param string) (interface{}, error) {
+		v, err := convertValue(ty, param)
+		if err != nil {
+			return nil, BadRequest(fmt.Errorf("invalid id '%s'", param))
+		}
+
+		return v, nil
+	}
+}
+
+func whereKey(db *gorm.DB, key interface{}) (*gorm.DB, error) {
+	sch, err := parseSchema(db, db.Statement.Model)
+	if err != nil {
+		return db, err
+	}
+
+	if composite, ok := key.(map[string]interface{}); ok {
+		for name, v := range composite {
+			field := sch.LookUpField(name)
+			if field == nil || !field.PrimaryKey {
+				return db, BadRequest(fmt.Errorf("unknown key column '%s'", name))
+			}
+			db = db.Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: v})
+		}
+
+		return applyScopes(db)
+	}
+
+	if sch.PrioritizedPrimaryField == nil {
+		return db, errors.New("entity has no primary key")
+	}
+
+	return applyScopes(db.Where(clause.Eq{
+		Column: clause.Column{Table: clause.CurrentTable, Name: sch.PrioritizedPrimaryField.DBName},
+		Value:  key,
+	}))
+}
+
+func entityKey(db *gorm.DB, ent interface{}) (interface{}, error) {
+	sch, err := parseSchema(db, ent)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sch.PrimaryFields) == 0 {
+		return nil, errors.New("entity has no primary key")
+	}
+
+	rv := reflect.Indirect(reflect.ValueOf(ent))
+	if len(sch.PrimaryFields) == 1 {
+		v, _ := sch.PrimaryFields[0].ValueOf(db.Statement.Context, rv)
+		return v, nil
+	}
+
+	key := make(map[string]interface{}, len(sch.PrimaryFields))
+	for _, field := range sch.PrimaryFields {
+		key[field.Name], _ = field.ValueOf(db.Statement.Context, rv)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,57 @@
+package restapi_test
+
+import (
+	"errors"
+	"github.com/miniyus/go-restapi"
+	"reflect"
+	"testing"
+)
+
+type TestStringKeyEntity struct {
+	Slug string `gorm:"primaryKey"`
+}
+
+type TestUUIDKeyEntity struct {
+	ID string `gorm:"type:uuid;primaryKey"`
+}
+
+type TestCompositeKeyEntity struct {
+	TenantId uint   `gorm:"primaryKey"`
+	Code     string `gorm:"primaryKey"`
+}
+
+func TestKeyParserFor(t *testing.T) {
+	testCases := []struct {
+		model    interface{}
+		param    string
+		expected interface{}
+		err      bool
+	}{
+		{&TestEntity{}, "12", uint64(12), false},
+		{&TestEntity{}, "abc", nil, true},
+		{&TestStringKeyEntity{}, "hello-world", "hello-world", false},
+		{&TestUUIDKeyEntity{}, "3F2504E0-4F89-11D3-9A0C-0305E82C3301", "3f2504e0-4f89-11d3-9a0c-0305e82c3301", false},
+		{&TestUUIDKeyEntity{}, "not-a-uuid", nil, true},
+		{&TestCompositeKeyEntity{}, "7,abc", map[string]interface{}{"TenantId": uint64(7), "Code": "abc"}, false},
+		{&TestCompositeKeyEntity{}, "7", nil, true},
+	}
+
+	for _, testCase := range testCases {
+		key, err := restapi.KeyParserFor(testCase.model)(testCase.param)
+		if testCase.err {
+			if !errors.Is(err, restapi.ErrBadRequest) {
+				t.Errorf("%s: expected bad request, got %v", testCase.param, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %v", testCase.param, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(key, testCase.expected) {
+			t.Errorf("%s: expected %#v, got %#v", testCase.param, testCase.expected, key)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package restapi
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/miniyus/gofiber/app"
+)
+
+// ParentParam is the path parameter carrying the parent key of nested routes.
+const ParentParam = "parent_id"
+
+// RouteNested mounts the routes of child under /:parent_id/<child table>.
+func RouteNested[
+	P interface{}, PReq RequestDTO[*P], PRes ResponseDTO[P],
+	C interface{}, CReq RequestDTO[*C], CRes ResponseDTO[C],
+](parent Handler[P, PReq, PRes], child Handler[C, CReq, CRes], foreignKey string, opts ...RouteOption) app.SubRouter {
+	routes := Route(child, opts...)
+	return func(router fiber.Router) {
+		var ent C
+		sch, err := parseSchema(child.GetService().Repo().DB(), &ent)
+		if err != nil {
+			panic(err)
+		}
+
+		routes(router.Group("/:"+ParentParam+"/"+sch.Table, parentScope(parent, foreignKey)))
+	}
+}
+
+func parentScope[P interface{}, PReq RequestDTO[*P], PRes ResponseDTO[P]](parent Handler[P, PReq, PRes], foreignKey string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		key, err := parent.ParseKey(ctx.Params(ParentParam))
+		if err != nil {
+			return ErrorHandler(ctx, err)
+		}
+
+		// the service applies the policy and tenancy of the parent
+		_, err = parent.GetService().FindContext(ctx.UserContext(), key)
+		if errors.Is(err, ErrNotFound) {
+			return ErrorHandler(ctx, NotFound(errors.New("parent resource not found")))
+		}
+		if err != nil {
+			return ErrorHandler(ctx, err)
+		}
+
+		ctx.SetUserContext(WithScope(ctx.UserContext(), Scope{Column: foreignKey, Value: key}))
+		return ctx.Next()
+	}
+}
@@ -0,0 +1,85 @@
+package restapi_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/miniyus/go-restapi"
+	"github.com/miniyus/gofiber/app"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func (tr *TestRelationReq) ToEntity(ent *TestRelationModel) error {
+	ent.TestEntityId = tr.TestEntityId
+	ent.Seq = tr.Seq
+	return nil
+}
+
+func (tr *TestRelationRes) FromEntity(ent TestRelationModel) error {
+	tr.Id = ent.ID
+	tr.TestEntityId = ent.TestEntityId
+	tr.Seq = ent.Seq
+	return nil
+}
+
+func TestRouteNested(t *testing.T) {
+	child := restapi.NewHandler[TestRelationModel, *TestRelationReq, *TestRelationRes](
+		&TestRelationReq{},
+		restapi.NewService[TestRelationModel, *TestRelationReq, *TestRelationRes](
+			restapi.NewRepository[TestRelationModel](db, TestRelationModel{}),
+			&TestRelationRes{},
+		),
+	)
+	restapi.RouteNested(h, child, "test_entity_id")(app.App().Fiber().Group("/nested-tests"))
+
+	req := httptest.NewRequest("POST", "/nested-tests/1/test_relation_models", bytes.NewReader([]byte(`{"test_entity_id":2,"seq":7}`)))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	test, err := app.App().Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var created TestRelationRes
+	if err = json.NewDecoder(test.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+
+	if created.TestEntityId != 1 {
+		t.Errorf("expected the parent key to be stamped, got %+v", created)
+	}
+
+	test, err = app.App().Test(httptest.NewRequest("GET", "/nested-tests/1/test_relation_models", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var list restapi.Paginated[TestRelationRes]
+	if err = json.NewDecoder(test.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, item := range list.Data {
+		if item.TestEntityId != 1 {
+			t.Errorf("expected only children of parent 1, got %+v", item)
+		}
+	}
+
+	testCases := map[string]int{
+		"/nested-tests/999999/test_relation_models":                                          fiber.StatusNotFound,
+		"/nested-tests/2/test_relation_models/" + strconv.FormatUint(uint64(created.Id), 10): fiber.StatusNotFound,
+		"/nested-tests/1/test_relation_models/" + strconv.FormatUint(uint64(created.Id), 10): fiber.StatusOK,
+	}
+
+	for url, status := range testCases {
+		test, err = app.App().Test(httptest.NewRequest("GET", url, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if test.StatusCode != status {
+			t.Errorf("%s: expected %d, got %d", url, status, test.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,721 @@
+package restapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/miniyus/gofiber/app"
+	"gorm.io/gorm/schema"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const componentsPrefix = "#/components/schemas/"
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	fiberParam     = regexp.MustCompile(`:([A-Za-z0-9_]+)`)
+	templateParam  = regexp.MustCompile(`{([A-Za-z0-9_]+)}`)
+	schemaNameChar = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+)
+
+// OpenAPI is an OpenAPI 3.1 document of the resources registered with Document.
+type OpenAPI struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       OpenAPIInfo                      `json:"info"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+	mu         sync.RWMutex
+}
+
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Components struct {
+	Schemas map[string]*JSONSchema `json:"schemas"`
+}
+
+type Operation struct {
+	OperationId string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name        string      `json:"name"`
+	In          string      `json:"in"`
+	Description string      `json:"description,omitempty"`
+	Required    bool        `json:"required,omitempty"`
+	Style       string      `json:"style,omitempty"`
+	Explode     bool        `json:"explode,omitempty"`
+	Schema      *JSONSchema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *JSONSchema `json:"schema"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Headers     map[string]Header    `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Header struct {
+	Description string      `json:"description,omitempty"`
+	Schema      *JSONSchema `json:"schema"`
+}
+
+// JSONSchema is the subset of JSON Schema 2020-12 used by the generated documents.
+type JSONSchema struct {
+	Ref                  string                 `json:"$ref,omitempty"`
+	Type                 interface{}            `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"`
+	AllOf                []*JSONSchema          `json:"allOf,omitempty"`
+	Enum                 []interface{}          `json:"enum,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64               `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64               `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	MaxLength            *int                   `json:"maxLength,omitempty"`
+	MinItems             *int                   `json:"minItems,omitempty"`
+	MaxItems             *int                   `json:"maxItems,omitempty"`
+}
+
+func NewOpenAPI(title string, version string) *OpenAPI {
+	doc := &OpenAPI{
+		OpenAPI:    "3.1.0",
+		Info:       OpenAPIInfo{Title: title, Version: version},
+		Paths:      make(map[string]map[string]*Operation),
+		Components: Components{Schemas: make(map[string]*JSONSchema)},
+	}
+	doc.schemaOf(reflect.TypeOf(Problem{}))
+
+	return doc
+}
+
+// Serve writes the document as JSON.
+func (doc *OpenAPI) Serve(ctx *fiber.Ctx) error {
+	doc.mu.RLock()
+	defer doc.mu.RUnlock()
+
+	return ctx.JSON(doc)
+}
+
+// Route registers GET /openapi.json.
+func (doc *OpenAPI) Route(router fiber.Router) {
+	router.Get("/openapi.json", doc.Serve)
+}
+
+// Document registers the routes of handler like Route and adds them to doc.
+func Document[Entity interface{}, Req RequestDTO[*Entity], Res ResponseDTO[Entity]](doc *OpenAPI, handler Handler[Entity, Req, Res], opts ...RouteOption) app.SubRouter {
+	config := newRouteConfig(opts)
+	list := routes(handler, config)
+	return func(router fiber.Router) {
+		for _, r := range list {
+			router.Add(r.method, r.path, r.handlers...)
+		}
+
+		prefix := routerPrefix(router)
+		var ent Entity
+		sch, err := parseSchema(handler.GetService().Repo().DB(), &ent)
+		if err != nil {
+			panic(err)
+		}
+
+		doc.mu.Lock()
+		defer doc.mu.Unlock()
+
+		spec := &resourceSpec{
+			tag:        sch.Table,
+			key:        doc.keySchema(sch),
+			req:        NewDTOFactory(*new(Req))(),
+			res:        NewDTOFactory(*new(Res))(),
+			sch:        sch,
+			idParam:    config.idParam,
+			softDelete: softDeleteField(sch) != nil,
+		}
+
+		for _, r := range list {
+			path := openAPIPath(prefix + r.path)
+			if doc.Paths[path] == nil {
+				doc.Paths[path] = make(map[string]*Operation)
+			}
+			doc.Paths[path][strings.ToLower(r.method)] = doc.operation(spec, r, path)
+		}
+	}
+}
+
+type resourceSpec struct {
+	tag        string
+	key        *JSONSchema
+	req        interface{}
+	res        interface{}
+	sch        *schema.Schema
+	idParam    string
+	softDelete bool
+}
+
+func (doc *OpenAPI) keySchema(sch *schema.Schema) *JSONSchema {
+	if len(sch.PrimaryFields) == 1 {
+		return doc.schemaOf(sch.PrimaryFields[0].FieldType)
+	}
+
+	return &JSONSchema{
+		Type:        "string",
+		Description: fmt.Sprintf("Primary key columns joined by '%s'.", CompositeKeySeparator),
+	}
+}
+
+func openAPIPath(path string) string {
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+
+	return fiberParam.ReplaceAllString(path, "{$1}")
+}
+
+func (doc *OpenAPI) operation(spec *resourceSpec, r route, path string) *Operation {
+	op := &Operation{Tags: []string{spec.tag}, Responses: make(map[string]*Response)}
+	for _, match := range templateParam.FindAllStringSubmatch(path, -1) {
+		param := Parameter{Name: match[1], In: "path", Required: true, Schema: &JSONSchema{Type: "string"}}
+		if match[1] == spec.idParam {
+			param.Schema = spec.key
+		}
+		op.Parameters = append(op.Parameters, param)
+	}
+
+	res := doc.schemaOf(reflect.TypeOf(spec.res))
+	req := doc.schemaOf(reflect.TypeOf(spec.req))
+	bulk := strings.HasSuffix(r.path, "/bulk")
+	ifMatch := Parameter{Name: fiber.HeaderIfMatch, In: "header", Description: "Entity tag of the version the change is based on.", Schema: &JSONSchema{Type: "string"}}
+
+	switch {
+	case r.action != "":
+		op.OperationId = fmt.Sprintf("%s_%s", r.action, spec.tag)
+		op.Summary = fmt.Sprintf("Run %s on a %s record", r.action, spec.tag)
+		op.Responses["200"] = &Response{Description: http.StatusText(http.StatusOK)}
+		doc.problems(op, http.StatusBadRequest, http.StatusNotFound)
+	case bulk && r.event == Create:
+		op.OperationId = "bulk_create_" + spec.tag
+		op.Summary = fmt.Sprintf("Create many %s records", spec.tag)
+		op.RequestBody = jsonBody(&JSONSchema{Type: "array", Items: req})
+		bulkResponses(op, http.StatusCreated, res)
+		doc.problems(op, http.StatusBadRequest)
+	case bulk && r.event == Patch:
+		op.OperationId = "bulk_patch_" + spec.tag
+		op.Summary = fmt.Sprintf("Patch many %s records", spec.tag)
+		item := &JSONSchema{AllOf: []*JSONSchema{
+			doc.partialOf(reflect.TypeOf(spec.req)),
+			{Type: "object", Properties: map[string]*JSONSchema{"id": spec.key}, Required: []string{"id"}},
+		}}
+		op.RequestBody = jsonBody(&JSONSchema{Type: "array", Items: item})
+		bulkResponses(op, http.StatusOK, res)
+		doc.problems(op, http.StatusBadRequest)
+	case bulk && r.event == Delete:
+		op.OperationId = "bulk_delete_" + spec.tag
+		op.Summary = fmt.Sprintf("Delete many %s records by ids or by filter", spec.tag)
+		op.Parameters = append(op.Parameters, doc.searchParameters(spec)...)
+		op.RequestBody = jsonBody(&JSONSchema{
+			Type:       "object",
+			Properties: map[string]*JSONSchema{"ids": {Type: "array", Items: spec.key}},
+		})
+		op.RequestBody.Required = false
+		bulkResponses(op, http.StatusOK, res)
+		doc.problems(op, http.StatusBadRequest)
+	case r.event == All:
+		op.OperationId = "list_" + spec.tag
+		op.Summary = fmt.Sprintf("List %s records", spec.tag)
+		op.Parameters = append(op.Parameters, doc.listParameters(spec)...)
+		op.Responses["200"] = &Response{
+			Description: http.StatusText(http.StatusOK),
+			Content:     jsonContent(paginatedSchema(res)),
+		}
+		doc.problems(op, http.StatusBadRequest)
+	case r.event == Find:
+		op.OperationId = "find_" + spec.tag
+		op.Summary = fmt.Sprintf("Find a %s record", spec.tag)
+		op.Parameters = append(op.Parameters,
+			fieldsParameter(),
+			includeParameter(spec),
+			Parameter{Name: fiber.HeaderIfNoneMatch, In: "header", Schema: &JSONSchema{Type: "string"}},
+		)
+		op.Responses["200"] = entityResponse(http.StatusOK, res)
+		op.Responses["304"] = &Response{Description: http.StatusText(http.StatusNotModified)}
+		doc.problems(op, http.StatusBadRequest, http.StatusNotFound)
+	case r.event == Create:
+		op.OperationId = "create_" + spec.tag
+		op.Summary = fmt.Sprintf("Create a %s record", spec.tag)
+		op.RequestBody = jsonBody(req)
+		op.Responses["201"] = entityResponse(http.StatusCreated, res)
+		doc.problems(op, http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity)
+	case r.event == Update:
+		op.OperationId = "update_" + spec.tag
+		op.Summary = fmt.Sprintf("Replace a %s record", spec.tag)
+		op.Parameters = append(op.Parameters, ifMatch)
+		op.RequestBody = jsonBody(req)
+		op.Responses["200"] = entityResponse(http.StatusOK, res)
+		doc.problems(op, http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed, http.StatusUnprocessableEntity)
+	case r.event == Patch:
+		op.OperationId = "patch_" + spec.tag
+		op.Summary = fmt.Sprintf("Patch a %s record", spec.tag)
+		op.Parameters = append(op.Parameters, ifMatch)
+		partial := doc.partialOf(reflect.TypeOf(spec.req))
+		op.RequestBody = &RequestBody{Required: true, Content: map[string]MediaType{
+			fiber.MIMEApplicationJSON: {Schema: partial},
+			MergePatchContentType:     {Schema: partial},
+			JSONPatchContentType:      {Schema: &JSONSchema{Type: "array", Items: jsonPatchSchema()}},
+		}}
+		op.Responses["200"] = entityResponse(http.StatusOK, res)
+		doc.problems(op, http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed, http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity)
+	case r.event == Delete:
+		op.OperationId = "delete_" + spec.tag
+		op.Summary = fmt.Sprintf("Delete a %s record", spec.tag)
+		op.Parameters = append(op.Parameters, ifMatch)
+		if spec.softDelete {
+			op.Parameters = append(op.Parameters, Parameter{
+				Name:        "force",
+				In:          "query",
+				Description: "Delete the record permanently instead of trashing it.",
+				Schema:      &JSONSchema{Type: "boolean"},
+			})
+		}
+		op.Responses["204"] = &Response{Description: http.StatusText(http.StatusNoContent)}
+		doc.problems(op, http.StatusBadRequest, http.StatusNotFound, http.StatusPreconditionFailed)
+	case r.event == Restore:
+		op.OperationId = "restore_" + spec.tag
+		op.Summary = fmt.Sprintf("Restore a trashed %s record", spec.tag)
+		op.Responses["200"] = &Response{Description: http.StatusText(http.StatusOK), Content: jsonContent(res)}
+		doc.problems(op, http.StatusBadRequest, http.StatusNotFound, http.StatusConflict)
+	}
+
+	return op
+}
+
+func (doc *OpenAPI) problems(op *Operation, statuses ...int) {
+	problem := doc.schemaOf(reflect.TypeOf(Problem{}))
+	for _, status := range statuses {
+		op.Responses[strconv.Itoa(status)] = &Response{
+			Description: http.StatusText(status),
+			Content:     map[string]MediaType{ProblemContentType: {Schema: problem}},
+		}
+	}
+}
+
+func (doc *OpenAPI) listParameters(spec *resourceSpec) []Parameter {
+	integer := func(min float64) *JSONSchema {
+		return &JSONSchema{Type: "integer", Minimum: &min}
+	}
+
+	sort := "Comma separated columns, prefixed with - for descending order."
+	if sortable, ok := spec.req.(Sortable); ok {
+		sort += " Allowed columns: " + strings.Join(sortable.SortColumns(), ", ") + "."
+	}
+
+	params := []Parameter{
+		{Name: "page", In: "query", Schema: integer(1)},
+		{Name: "page_size", In: "query", Schema: integer(1)},
+		{Name: "cursor", In: "query", Description: "Keyset pagination token from next_cursor or prev_cursor.", Schema: &JSONSchema{Type: "string"}},
+		{Name: "sort", In: "query", Description: sort, Schema: &JSONSchema{Type: "string"}},
+		fieldsParameter(),
+		includeParameter(spec),
+	}
+
+	return append(params, doc.searchParameters(spec)...)
+}
+
+func (doc *OpenAPI) searchParameters(spec *resourceSpec) []Parameter {
+	params := make([]Parameter, 0)
+	seen := make(map[string]bool)
+	ty := reflect.TypeOf(spec.req)
+	for ty.Kind() == reflect.Ptr {
+		ty = ty.Elem()
+	}
+
+	for _, field := range exportedFields(ty) {
+		name := strings.Split(field.Tag.Get("query"), ",")[0]
+		if name == "" || name == "-" || seen[name] {
+			continue
+		}
+
+		seen[name] = true
+		params = append(params, Parameter{Name: name, In: "query", Schema: doc.schemaOf(field.Type)})
+	}
+
+	if searchable, ok := spec.req.(Searchable); ok {
+		for _, column := range searchable.SearchColumns() {
+			if seen[column] {
+				continue
+			}
+			seen[column] = true
+
+			value := &JSONSchema{Type: "string"}
+			if field := spec.sch.LookUpField(column); field != nil {
+				value = doc.schemaOf(field.FieldType)
+			}
+
+			list := &JSONSchema{Type: "string", Description: "Comma separated values."}
+			params = append(params, Parameter{
+				Name:        column,
+				In:          "query",
+				Description: fmt.Sprintf("Filters on %s, e.g. %s[gte]=value.", column, column),
+				Style:       "deepObject",
+				Explode:     true,
+				Schema: &JSONSchema{Type: "object", Properties: map[string]*JSONSchema{
+					string(Eq): value, string(Ne): value,
+					string(Gt): value, string(Gte): value,
+					string(Lt): value, string(Lte): value,
+					string(Like):  {Type: "string"},
+					string(In):    list,
+					string(NotIn): list,
+					string(Null):  {Type: "boolean"},
+				}},
+			})
+		}
+	}
+
+	if spec.softDelete {
+		params = append(params, Parameter{
+			Name:        "trashed",
+			In:          "query",
+			Description: "Include trashed records, or list only them.",
+			Schema:      &JSONSchema{Type: "string", Enum: []interface{}{string(WithTrashed), string(OnlyTrashed)}},
+		})
+	}
+
+	return params
+}
+
+func fieldsParameter() Parameter {
+	return Parameter{
+		Name:        "fields",
+		In:          "query",
+		Description: "Comma separated JSON fields of the response, e.g. id,relation.column.",
+		Schema:      &JSONSchema{Type: "string"},
+	}
+}
+
+func includeParameter(spec *resourceSpec) Parameter {
+	description := "Comma separated relations to load, e.g. relation,relation.child."
+	if includable, ok := spec.req.(Includable); ok {
+		description += " Allowed relations: " + strings.Join(includable.IncludeRelations(), ", ") + "."
+	}
+
+	return Parameter{Name: "include", In: "query", Description: description, Schema: &JSONSchema{Type: "string"}}
+}
+
+func jsonContent(s *JSONSchema) map[string]MediaType {
+	return map[string]MediaType{fiber.MIMEApplicationJSON: {Schema: s}}
+}
+
+func jsonBody(s *JSONSchema) *RequestBody {
+	return &RequestBody{Required: true, Content: jsonContent(s)}
+}
+
+func entityResponse(status int, res *JSONSchema) *Response {
+	return &Response{
+		Description: http.StatusText(status),
+		Headers:     map[string]Header{fiber.HeaderETag: {Description: "Current version of the record.", Schema: &JSONSchema{Type: "string"}}},
+		Content:     jsonContent(res),
+	}
+}
+
+func bulkResponses(op *Operation, success int, res *JSONSchema) {
+	result := &JSONSchema{Type: "object", Properties: map[string]*JSONSchema{
+		"items": {Type: "array", Items: &JSONSchema{Type: "object", Properties: map[string]*JSONSchema{
+			"index":  {Type: "integer"},
+			"status": {Type: "integer"},
+			"data":   res,
+			"error":  {Ref: componentsPrefix + "Problem"},
+		}}},
+		"succeeded": {Type: "integer"},
+		"failed":    {Type: "integer"},
+		"committed": {Type: "boolean"},
+	}}
+
+	for _, status := range []int{success, http.StatusMultiStatus, http.StatusUnprocessableEntity} {
+		op.Responses[strconv.Itoa(status)] = &Response{Description: http.StatusText(status), Content: jsonContent(result)}
+	}
+}
+
+func paginatedSchema(res *JSONSchema) *JSONSchema {
+	return &JSONSchema{Type: "object", Properties: map[string]*JSONSchema{
+		"data":        {Type: "array", Items: res},
+		"total":       {Type: "integer"},
+		"page":        {Type: "integer"},
+		"page_size":   {Type: "integer"},
+		"total_pages": {Type: "integer"},
+		"next_cursor": {Type: "string"},
+		"prev_cursor": {Type: "string"},
+	}}
+}
+
+func jsonPatchSchema() *JSONSchema {
+	return &JSONSchema{
+		Type: "object",
+		Properties: map[string]*JSONSchema{
+			"op":    {Type: "string", Enum: []interface{}{"add", "remove", "replace"}},
+			"path":  {Type: "string"},
+			"value": {},
+		},
+		Required: []string{"op", "path"},
+	}
+}
+
+func (doc *OpenAPI) schemaOf(ty reflect.Type) *JSONSchema {
+	for ty.Kind() == reflect.Ptr {
+		ty = ty.Elem()
+	}
+
+	switch ty {
+	case timeType:
+		return &JSONSchema{Type: "string", Format: "date-time"}
+	case deletedAtType:
+		return &JSONSchema{Type: []string{"string", "null"}, Format: "date-time"}
+	case rawMessageType:
+		return &JSONSchema{}
+	}
+
+	switch ty.Kind() {
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &JSONSchema{Type: "integer", Format: primitiveFormat(ty)}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		min := 0.0
+		return &JSONSchema{Type: "integer", Format: primitiveFormat(ty), Minimum: &min}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number", Format: primitiveFormat(ty)}
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if ty.Elem().Kind() == reflect.Uint8 {
+			return &JSONSchema{Type: "string", Format: "byte"}
+		}
+		return &JSONSchema{Type: "array", Items: doc.schemaOf(ty.Elem())}
+	case reflect.Map:
+		return &JSONSchema{Type: "object", AdditionalProperties: doc.schemaOf(ty.Elem())}
+	case reflect.Struct:
+		if ty.Name() == "" {
+			return doc.objectOf(ty)
+		}
+		return doc.componentOf(ty)
+	}
+
+	return &JSONSchema{}
+}
+
+func primitiveFormat(ty reflect.Type) string {
+	switch ty.Kind() {
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return "int64"
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return "int32"
+	case reflect.Float32:
+		return "float"
+	case reflect.Float64:
+		return "double"
+	}
+
+	return ""
+}
+
+func (doc *OpenAPI) componentOf(ty reflect.Type) *JSONSchema {
+	name := schemaNameChar.ReplaceAllString(ty.Name(), "_")
+	ref := &JSONSchema{Ref: componentsPrefix + name}
+	if _, ok := doc.Components.Schemas[name]; ok {
+		return ref
+	}
+
+	// registered before its fields so that recursive types end in a $ref
+	object := &JSONSchema{Type: "object"}
+	doc.Components.Schemas[name] = object
+	*object = *doc.objectOf(ty)
+
+	return ref
+}
+
+func (doc *OpenAPI) partialOf(ty reflect.Type) *JSONSchema {
+	return doc.partial(doc.schemaOf(ty))
+}
+
+func (doc *OpenAPI) partial(schema *JSONSchema) *JSONSchema {
+	if schema == nil {
+		return nil
+	}
+
+	if schema.Ref != "" {
+		name := strings.TrimPrefix(schema.Ref, componentsPrefix)
+		if _, ok := doc.Components.Schemas[name+"Patch"]; !ok {
+			// registered before its properties so that recursive types end in a $ref
+			partial := &JSONSchema{}
+			doc.Components.Schemas[name+"Patch"] = partial
+			*partial = *doc.partial(doc.Components.Schemas[name])
+		}
+
+		return &JSONSchema{Ref: componentsPrefix + name + "Patch"}
+	}
+
+	partial := *schema
+	partial.Required = nil
+	partial.Items = doc.partial(schema.Items)
+	partial.AdditionalProperties = doc.partial(schema.AdditionalProperties)
+	if schema.Properties != nil {
+		partial.Properties = make(map[string]*JSONSchema, len(schema.Properties))
+		for name, property := range schema.Properties {
+			partial.Properties[name] = doc.partial(property)
+		}
+	}
+
+	if schema.AllOf != nil {
+		partial.AllOf = make([]*JSONSchema, len(schema.AllOf))
+		for i, sub := range schema.AllOf {
+			partial.AllOf[i] = doc.partial(sub)
+		}
+	}
+
+	return &partial
+}
+
+func (doc *OpenAPI) objectOf(ty reflect.Type) *JSONSchema {
+	object := &JSONSchema{Type: "object", Properties: make(map[string]*JSONSchema)}
+	for _, field := range exportedFields(ty) {
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" {
+			name = field.Name
+		}
+
+		property := doc.schemaOf(field.Type)
+		if applyValidate(property, field.Tag.Get("validate")) {
+			object.Required = append(object.Required, name)
+		}
+		object.Properties[name] = property
+	}
+
+	return object
+}
+
+func exportedFields(ty reflect.Type) []reflect.StructField {
+	fields := make([]reflect.StructField, 0, ty.NumField())
+	for i := 0; i < ty.NumField(); i++ {
+		field := ty.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			for embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+
+			if embedded.Kind() == reflect.Struct {
+				fields = append(fields, exportedFields(embedded)...)
+				continue
+			}
+		}
+
+		if field.IsExported() {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields
+}
+
+func applyValidate(s *JSONSchema, tag string) bool {
+	required := false
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "dive":
+			return required
+		case "required":
+			required = true
+		case "email":
+			s.Format = "email"
+		case "url", "uri":
+			s.Format = "uri"
+		case "uuid", "uuid4":
+			s.Format = "uuid"
+		case "oneof":
+			for _, v := range strings.Fields(param) {
+				if n, err := strconv.ParseFloat(v, 64); err == nil && s.Type != "string" {
+					s.Enum = append(s.Enum, n)
+				} else {
+					s.Enum = append(s.Enum, v)
+				}
+			}
+		case "min", "gte":
+			bound(s, param, true)
+		case "max", "lte":
+			bound(s, param, false)
+		case "len":
+			bound(s, param, true)
+			bound(s, param, false)
+		case "gt", "lt":
+			if n, err := strconv.ParseFloat(param, 64); err == nil && (s.Type == "integer" || s.Type == "number") {
+				if name == "gt" {
+					s.ExclusiveMinimum = &n
+				} else {
+					s.ExclusiveMaximum = &n
+				}
+			}
+		}
+	}
+
+	return required
+}
+
+func bound(s *JSONSchema, param string, lower bool) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+
+	size := int(n)
+	switch s.Type {
+	case "string":
+		if lower {
+			s.MinLength = &size
+		} else {
+			s.MaxLength = &size
+		}
+	case "array":
+		if lower {
+			s.MinItems = &size
+		} else {
+			s.MaxItems = &size
+		}
+	case "integer", "number":
+		if lower {
+			s.Minimum = &n
+		} else {
+			s.Maximum = &n
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package restapi_test
+
+import (
+	"encoding/json"
+	"github.com/miniyus/go-restapi"
+	"github.com/miniyus/gofiber/app"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDocument(t *testing.T) {
+	handler := newTestHandler()
+
+	doc := restapi.NewOpenAPI("test", "1.0.0")
+	restapi.Document(doc, handler)(app.App().Fiber().Group("/openapi-tests"))
+	doc.Route(app.App().Fiber().Group("/openapi-tests-doc"))
+
+	test, err := app.App().Test(httptest.NewRequest("GET", "/openapi-tests-doc/openapi.json", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var spec restapi.OpenAPI
+	if err = json.NewDecoder(test.Body).Decode(&spec); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := map[string]string{
+		"/openapi-tests":              "get",
+		"/openapi-tests/{id}":         "patch",
+		"/openapi-tests/bulk":         "post",
+		"/openapi-tests/{id}/restore": "post",
+	}
+
+	for path, method := range testCases {
+		if spec.Paths[path][method] == nil {
+			t.Errorf("missing %s %s", method, path)
+		}
+	}
+
+	if op := spec.Paths["/openapi-tests/{id}"]["delete"]; op == nil || op.Responses["204"] == nil {
+		t.Error("expected DELETE to be documented as 204 No Content")
+	}
+
+	nested, ok := spec.Components.Schemas["TestRelationReqPatch"]
+	if !ok || len(nested.Required) != 0 {
+		t.Errorf("expected nested PATCH schemas without required properties, got %+v", nested)
+	}
+
+	req, ok := spec.Components.Schemas["TestReq"]
+	if !ok {
+		t.Fatal("missing TestReq schema")
+	}
+
+	if len(req.Required) == 0 || req.Required[0] != "name" {
+		t.Errorf("expected name to be required, got %v", req.Required)
+	}
+}
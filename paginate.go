@@ -0,0 +1,88 @@
+package restapi
+
+import (
+	"encoding/json"
+	"github.com/miniyus/gofiber/pagination"
+)
+
+const (
+	DefaultPageSize = 10
+	MaxPageSize     = 100
+)
+
+func clampPageSize(size int) int {
+	if size <= 0 {
+		return DefaultPageSize
+	}
+
+	if size > MaxPageSize {
+		return MaxPageSize
+	}
+
+	return size
+}
+
+type Paginated[T interface{}] struct {
+	Data       []T    `json:"data"`
+	Total      int64  `json:"total"`
+	Page       int    `json:"page"`
+	PageSize   int    `json:"page_size"`
+	TotalPages int    `json:"total_pages"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	keyset     bool
+}
+
+// NewKeysetPaginated wraps a page of cursor pagination.
+func NewKeysetPaginated[T interface{}](data []T, pageSize int, cursor *Cursor) *Paginated[T] {
+	if data == nil {
+		data = make([]T, 0)
+	}
+
+	return &Paginated[T]{
+		Data:       data,
+		PageSize:   pageSize,
+		NextCursor: cursor.Next(),
+		PrevCursor: cursor.Prev(),
+		keyset:     true,
+	}
+}
+
+// MarshalJSON leaves the page and total fields out of keyset pages.
+func (p Paginated[T]) MarshalJSON() ([]byte, error) {
+	if p.keyset {
+		return json.Marshal(struct {
+			Data       []T    `json:"data"`
+			PageSize   int    `json:"page_size"`
+			NextCursor string `json:"next_cursor,omitempty"`
+			PrevCursor string `json:"prev_cursor,omitempty"`
+		}{p.Data, p.PageSize, p.NextCursor, p.PrevCursor})
+	}
+
+	type page Paginated[T]
+	return json.Marshal(page(p))
+}
+
+func NewPaginated[T interface{}](data []T, total int64, page pagination.Page) *Paginated[T] {
+	if data == nil {
+		data = make([]T, 0)
+	}
+
+	current := page.Page
+	if current < 1 {
+		current = 1
+	}
+
+	totalPages := 1
+	if page.PageSize > 0 {
+		totalPages = int((total + int64(page.PageSize) - 1) / int64(page.PageSize))
+	}
+
+	return &Paginated[T]{
+		Data:       data,
+		Total:      total,
+		Page:       current,
+		PageSize:   page.PageSize,
+		TotalPages: totalPages,
+	}
+}
@@ -0,0 +1,332 @@
+package restapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/miniyus/gofiber/utils"
+	"gorm.io/gorm/schema"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	MergePatchContentType = "application/merge-patch+json"
+	JSONPatchContentType  = "application/json-patch+json"
+)
+
+// Patchable is implemented by request DTOs that map PATCH fields onto columns.
+type Patchable interface {
+	PatchColumns(fields []string) []string
+}
+
+type jsonPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+// ParsePatch decodes a PATCH body into dto and returns the JSON paths present in it.
+func ParsePatch(ctx *fiber.Ctx, dto interface{}) ([]string, error) {
+	body := ctx.Body()
+	contentType := strings.ToLower(strings.TrimSpace(strings.Split(ctx.Get(fiber.HeaderContentType), ";")[0]))
+
+	var doc map[string]interface{}
+	switch contentType {
+	case fiber.MIMEApplicationJSON, MergePatchContentType:
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		if err := decoder.Decode(&doc); err != nil {
+			return nil, BadRequest(err)
+		}
+	case JSONPatchContentType:
+		var err error
+		doc, err = mergeDocument(body)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fiber.ErrUnsupportedMediaType
+	}
+
+	return decodePatch(doc, dto)
+}
+
+func decodePatch(doc map[string]interface{}, dto interface{}) ([]string, error) {
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(body, dto); err != nil {
+		return nil, BadRequest(err)
+	}
+
+	fields := make([]string, 0)
+	collectPaths(doc, "", &fields)
+	sort.Strings(fields)
+
+	return fields, nil
+}
+
+func mergeDocument(body []byte) (map[string]interface{}, error) {
+	var ops []jsonPatchOperation
+	if err := json.Unmarshal(body, &ops); err != nil {
+		return nil, BadRequest(err)
+	}
+
+	doc := make(map[string]interface{})
+	for _, op := range ops {
+		if !strings.HasPrefix(op.Path, "/") || len(op.Path) < 2 {
+			return nil, BadRequest(fmt.Errorf("invalid patch path '%s'", op.Path))
+		}
+
+		segments := strings.Split(op.Path[1:], "/")
+		for i, seg := range segments {
+			segments[i] = strings.ReplaceAll(strings.ReplaceAll(seg, "~1", "/"), "~0", "~")
+		}
+
+		var value interface{}
+		switch op.Op {
+		case "add", "replace":
+			decoder := json.NewDecoder(bytes.NewReader(op.Value))
+			decoder.UseNumber()
+			if err := decoder.Decode(&value); err != nil {
+				return nil, BadRequest(fmt.Errorf("%s %s: %w", op.Op, op.Path, err))
+			}
+		case "remove":
+			value = nil
+		default:
+			return nil, BadRequest(fmt.Errorf("unsupported patch operation '%s'", op.Op))
+		}
+
+		parent := doc
+		for _, seg := range segments[:len(segments)-1] {
+			child, ok := parent[seg].(map[string]interface{})
+			if !ok {
+				child = make(map[string]interface{})
+				parent[seg] = child
+			}
+			parent = child
+		}
+		parent[segments[len(segments)-1]] = value
+	}
+
+	return doc, nil
+}
+
+func collectPaths(doc map[string]interface{}, prefix string, paths *[]string) {
+	for key, value := range doc {
+		path := prefix + key
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) != 0 {
+			collectPaths(nested, path+".", paths)
+			continue
+		}
+		*paths = append(*paths, path)
+	}
+}
+
+// ValidateStruct runs every validate tag of dto through utils.HandleValidate.
+func ValidateStruct(ctx *fiber.Ctx, dto interface{}) error {
+	if errRes := utils.HandleValidate(ctx, dto); errRes != nil {
+		return Validation(errRes)
+	}
+
+	return nil
+}
+
+// ValidatePartial runs the validate tags of dto for the given JSON paths only.
+func ValidatePartial(ctx *fiber.Ctx, dto interface{}, fields []string) error {
+	ty := reflect.TypeOf(dto)
+	for ty.Kind() == reflect.Ptr {
+		ty = ty.Elem()
+	}
+
+	if ty.Kind() != reflect.Struct {
+		return nil
+	}
+
+	partial, ok := partialType(ty, fields)
+	if !ok {
+		return nil
+	}
+
+	body, err := json.Marshal(dto)
+	if err != nil {
+		return err
+	}
+
+	value := reflect.New(partial)
+	if err = json.Unmarshal(body, value.Interface()); err != nil {
+		return err
+	}
+
+	return ValidateStruct(ctx, value.Interface())
+}
+
+func partialType(ty reflect.Type, paths []string) (reflect.Type, bool) {
+	nested := make(map[string][]string)
+	whole := make(map[string]bool)
+	for _, path := range paths {
+		key, rest, found := strings.Cut(path, ".")
+		if found {
+			nested[key] = append(nested[key], rest)
+		} else {
+			whole[key] = true
+		}
+	}
+
+	fields := make([]reflect.StructField, 0, len(paths))
+	for key := range whole {
+		if field, ok := jsonField(ty, key); ok {
+			fields = append(fields, reflect.StructField{Name: field.Name, Type: field.Type, Tag: field.Tag})
+		}
+	}
+
+	for key, rest := range nested {
+		field, ok := jsonField(ty, key)
+		if !ok || whole[key] {
+			continue
+		}
+
+		elem, ptr := field.Type, false
+		if elem.Kind() == reflect.Ptr {
+			elem, ptr = elem.Elem(), true
+		}
+
+		fieldType := field.Type
+		if elem.Kind() == reflect.Struct {
+			if partial, ok := partialType(elem, rest); ok {
+				fieldType = partial
+				if ptr {
+					fieldType = reflect.PtrTo(partial)
+				}
+			}
+		}
+		fields = append(fields, reflect.StructField{Name: field.Name, Type: fieldType, Tag: field.Tag})
+	}
+
+	if len(fields) == 0 {
+		return nil, false
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Name < fields[j].Name
+	})
+
+	return reflect.StructOf(fields), true
+}
+
+func structPath(ty reflect.Type, path string) (string, bool) {
+	names := make([]string, 0)
+	for _, seg := range strings.Split(path, ".") {
+		for ty.Kind() == reflect.Ptr {
+			ty = ty.Elem()
+		}
+
+		if ty.Kind() != reflect.Struct {
+			return "", false
+		}
+
+		field, ok := jsonField(ty, seg)
+		if !ok {
+			return "", false
+		}
+
+		names = append(names, field.Name)
+		ty = field.Type
+	}
+
+	return strings.Join(names, "."), true
+}
+
+func jsonField(ty reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < ty.NumField(); i++ {
+		field := ty.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := strings.Split(field.Tag.Get("json"), ",")[0]
+		if tag == "-" {
+			continue
+		}
+
+		if tag == name || (tag == "" && strings.EqualFold(field.Name, name)) {
+			return field, true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+func patchColumns(dto interface{}, fields []string, sch *schema.Schema) ([]string, error) {
+	if patchable, ok := dto.(Patchable); ok {
+		columns := patchable.PatchColumns(fields)
+		if len(columns) == 0 && len(fields) != 0 {
+			return nil, BadRequest(fmt.Errorf("fields %s can't be patched", strings.Join(fields, ", ")))
+		}
+
+		return columns, nil
+	}
+
+	ty := reflect.TypeOf(dto)
+	for ty.Kind() == reflect.Ptr {
+		ty = ty.Elem()
+	}
+
+	seen := make(map[string]bool)
+	columns := make([]string, 0, len(fields))
+	for _, path := range fields {
+		key := strings.Split(path, ".")[0]
+		candidates := []string{key}
+		if ty.Kind() == reflect.Struct {
+			if field, ok := jsonField(ty, key); ok {
+				candidates = append([]string{field.Name}, candidates...)
+			}
+		}
+
+		column := ""
+		for _, name := range candidates {
+			if field := sch.LookUpField(name); field != nil && field.DBName != "" && !field.PrimaryKey {
+				column = field.DBName
+			} else if rel, ok := sch.Relationships.Relations[name]; ok {
+				column = rel.Name
+			}
+
+			if column != "" {
+				break
+			}
+		}
+
+		if column == "" {
+			return nil, BadRequest(fmt.Errorf("field '%s' can't be patched", path))
+		}
+
+		if !seen[column] {
+			seen[column] = true
+			columns = append(columns, column)
+		}
+	}
+
+	return columns, nil
+}
+
+func mergeColumns(sch *schema.Schema, dst interface{}, src interface{}, columns []string) {
+	ctx := context.Background()
+	to := reflect.Indirect(reflect.ValueOf(dst))
+	from := reflect.Indirect(reflect.ValueOf(src))
+	for _, column := range columns {
+		field := sch.LookUpField(column)
+		if rel, ok := sch.Relationships.Relations[column]; field == nil && ok {
+			field = rel.Field
+		}
+
+		if field != nil {
+			field.ReflectValueOf(ctx, to).Set(field.ReflectValueOf(ctx, from))
+		}
+	}
+}
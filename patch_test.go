@@ -0,0 +1,114 @@
+package restapi_test
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/miniyus/go-restapi"
+	"github.com/valyala/fasthttp"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePatch(t *testing.T) {
+	testCases := []struct {
+		contentType string
+		body        string
+		fields      []string
+		name        string
+		status      int
+	}{
+		{fiber.MIMEApplicationJSON, `{"name":"patched"}`, []string{"name"}, "patched", fiber.StatusOK},
+		{restapi.MergePatchContentType, `{"test_relation":{"seq":3}}`, []string{"test_relation.seq"}, "", fiber.StatusOK},
+		{restapi.JSONPatchContentType, `[{"op":"replace","path":"/name","value":"json-patch"},{"op":"add","path":"/test_relation/seq","value":1}]`, []string{"name", "test_relation.seq"}, "json-patch", fiber.StatusOK},
+		{restapi.JSONPatchContentType, `[{"op":"move","from":"/name","path":"/title"}]`, nil, "", fiber.StatusBadRequest},
+		{fiber.MIMETextPlain, `name=patched`, nil, "", fiber.StatusUnsupportedMediaType},
+	}
+
+	for _, testCase := range testCases {
+		f := fiber.New(fiber.Config{ErrorHandler: restapi.ErrorHandler})
+		f.Patch("/", func(ctx *fiber.Ctx) error {
+			req := &TestReq{}
+			fields, err := restapi.ParsePatch(ctx, req)
+			if err != nil {
+				return err
+			}
+
+			if !reflect.DeepEqual(fields, testCase.fields) {
+				t.Errorf("expected fields %v, got %v", testCase.fields, fields)
+			}
+
+			if req.Name != testCase.name {
+				t.Errorf("expected name %s, got %s", testCase.name, req.Name)
+			}
+
+			return ctx.SendStatus(fiber.StatusOK)
+		})
+
+		req := httptest.NewRequest("PATCH", "/", strings.NewReader(testCase.body))
+		req.Header.Set("Content-Type", testCase.contentType)
+		test, err := f.Test(req)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if test.StatusCode != testCase.status {
+			t.Errorf("%s: expected status %d, got %d", testCase.body, testCase.status, test.StatusCode)
+		}
+	}
+}
+
+func TestValidatePartial(t *testing.T) {
+	f := fiber.New()
+	ctx := f.AcquireCtx(&fasthttp.RequestCtx{})
+	defer f.ReleaseCtx(ctx)
+
+	err := restapi.ValidatePartial(ctx, &TestReq{Name: "name"}, []string{"name"})
+	if err != nil {
+		t.Errorf("absent required fields should be skipped: %v", err)
+	}
+
+	err = restapi.ValidatePartial(ctx, &TestReq{}, []string{"name"})
+	if !errors.Is(err, restapi.ErrValidation) {
+		t.Errorf("expected validation error, got %v", err)
+	}
+}
+
+func TestGenericService_PatchUnmappedField(t *testing.T) {
+	s := newTestService()
+	data := makeFakeData(1)[0]
+	create, err := s.Create(&data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = s.Patch(create.Id, &data, "unknown")
+	if !errors.Is(err, restapi.ErrBadRequest) {
+		t.Errorf("expected bad request for an unmapped field, got %v", err)
+	}
+}
+
+func TestGenericService_PatchHookEntity(t *testing.T) {
+	s := newTestService()
+	data := makeFakeData(1)[0]
+	create, err := s.Create(&data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := ""
+	s.Hook().Patch().BeforeCallRepo(func(repo restapi.Repository[TestEntity], dto *TestReq, entity TestEntity) error {
+		seen = entity.Name
+		return nil
+	})
+
+	if _, err = s.Patch(create.Id, &TestReq{TestRelation: TestRelationReq{Seq: 2}}, "test_relation"); err != nil {
+		t.Fatal(err)
+	}
+
+	if seen != create.Name {
+		t.Errorf("expected hooks to see the stored name %q, got %q", create.Name, seen)
+	}
+}
@@ -0,0 +1,249 @@
+package restapi
+
+import (
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	"reflect"
+	"strings"
+)
+
+// Policy decides which records the caller of a request may read and write.
+type Policy[Entity interface{}] interface {
+	CanList(ctx context.Context) bool
+	CanView(ctx context.Context, ent *Entity) bool
+	// CanCreate receives the entity built from the request DTO.
+	CanCreate(ctx context.Context, ent *Entity) bool
+	// CanUpdate is asked with both the stored and the updated entity.
+	CanUpdate(ctx context.Context, ent *Entity) bool
+	CanDelete(ctx context.Context, ent *Entity) bool
+	// ListScope restricts listings to the rows the caller may see.
+	ListScope(ctx context.Context, db *gorm.DB) *gorm.DB
+}
+
+// AllowAll allows every action; embed it to implement only some rules.
+type AllowAll[Entity interface{}] struct{}
+
+func (AllowAll[Entity]) CanList(ctx context.Context) bool                { return true }
+func (AllowAll[Entity]) CanView(ctx context.Context, ent *Entity) bool   { return true }
+func (AllowAll[Entity]) CanCreate(ctx context.Context, ent *Entity) bool { return true }
+func (AllowAll[Entity]) CanUpdate(ctx context.Context, ent *Entity) bool { return true }
+func (AllowAll[Entity]) CanDelete(ctx context.Context, ent *Entity) bool { return true }
+
+func (AllowAll[Entity]) ListScope(ctx context.Context, db *gorm.DB) *gorm.DB {
+	return db
+}
+
+// FieldPolicy is implemented by policies that also restrict JSON fields.
+type FieldPolicy interface {
+	// ReadableFields are the response fields the caller may read, nil for all.
+	ReadableFields(ctx context.Context) []string
+	// WritableFields are the request fields the caller may write, nil for all.
+	WritableFields(ctx context.Context) []string
+}
+
+type listScopeKey struct{}
+
+func withListScope(ctx context.Context, scope func(db *gorm.DB) *gorm.DB) context.Context {
+	return context.WithValue(ctx, listScopeKey{}, scope)
+}
+
+func applyListScope(db *gorm.DB) *gorm.DB {
+	if db.Statement.Context == nil {
+		return db
+	}
+
+	if scope, ok := db.Statement.Context.Value(listScopeKey{}).(func(db *gorm.DB) *gorm.DB); ok {
+		return scope(db)
+	}
+
+	return db
+}
+
+func (s *GenericService[Entity, Req, Res]) SetPolicy(policy Policy[Entity]) {
+	s.policy = policy
+}
+
+func (s *GenericService[Entity, Req, Res]) Policy() Policy[Entity] {
+	return s.policy
+}
+
+func (s *GenericService[Entity, Req, Res]) authorize(ctx context.Context, event MethodEvent, ent *Entity) error {
+	if s.policy == nil {
+		return nil
+	}
+
+	var allowed bool
+	switch event {
+	case All:
+		allowed = s.policy.CanList(ctx)
+	case Find:
+		allowed = s.policy.CanView(ctx, ent)
+	case Create:
+		allowed = s.policy.CanCreate(ctx, ent)
+	case Update, Patch, Restore:
+		allowed = s.policy.CanUpdate(ctx, ent)
+	case Delete:
+		allowed = s.policy.CanDelete(ctx, ent)
+	}
+
+	if !allowed {
+		return Forbidden(fmt.Errorf("%s is not allowed", eventAction(event)))
+	}
+
+	return nil
+}
+
+func (s *GenericService[Entity, Req, Res]) readableFields(ctx context.Context) []string {
+	if fields, ok := s.policy.(FieldPolicy); ok {
+		return fields.ReadableFields(ctx)
+	}
+
+	return nil
+}
+
+func (s *GenericService[Entity, Req, Res]) writableFields(ctx context.Context) []string {
+	if fields, ok := s.policy.(FieldPolicy); ok {
+		return fields.WritableFields(ctx)
+	}
+
+	return nil
+}
+
+func (s *GenericService[Entity, Req, Res]) restrictWrite(repo Repository[Entity], dto Req, ent *Entity, base *Entity) error {
+	writable := s.writableFields(repo.DB().Statement.Context)
+	if writable == nil {
+		return nil
+	}
+
+	sch, err := parseSchema(repo.DB(), ent)
+	if err != nil {
+		return err
+	}
+
+	return keepColumns(sch, dto, ent, base, writable)
+}
+
+func (s *GenericService[Entity, Req, Res]) respond(event MethodEvent, repo Repository[Entity], res Res, ent Entity) (Res, error) {
+	err := s.afterCallRepo(event, repo, res, ent)
+	if err != nil {
+		return res, err
+	}
+
+	maskFields(res, s.readableFields(repo.DB().Statement.Context))
+	return res, nil
+}
+
+func checkWritable(fields []string, writable []string) error {
+	if writable == nil {
+		return nil
+	}
+
+	for _, path := range fields {
+		if !containsField(writable, strings.Split(path, ".")[0]) {
+			return Forbidden(fmt.Errorf("field '%s' is not writable", path))
+		}
+	}
+
+	return nil
+}
+
+func keepColumns(sch *schema.Schema, dto interface{}, ent interface{}, base interface{}, writable []string) error {
+	columns, err := patchColumns(dto, writable, sch)
+	if err != nil {
+		return fmt.Errorf("writable fields: %v", err)
+	}
+
+	ctx := context.Background()
+	dst := reflect.Indirect(reflect.ValueOf(ent))
+	src := reflect.Indirect(reflect.ValueOf(base))
+	for _, field := range sch.Fields {
+		if field.DBName == "" || field.PrimaryKey || containsField(columns, field.DBName) {
+			continue
+		}
+
+		field.ReflectValueOf(ctx, dst).Set(field.ReflectValueOf(ctx, src))
+	}
+
+	return nil
+}
+
+func maskFields(v interface{}, readable []string) {
+	if readable == nil {
+		return
+	}
+
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return
+		}
+		value = value.Elem()
+	}
+
+	if value.Kind() == reflect.Struct {
+		maskStruct(value, readable)
+	}
+}
+
+func maskStruct(value reflect.Value, readable []string) {
+	ty := value.Type()
+	for i := 0; i < ty.NumField(); i++ {
+		field := ty.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := strings.Split(field.Tag.Get("json"), ",")[0]
+		if field.Anonymous && tag == "" && field.Type.Kind() == reflect.Struct {
+			maskStruct(value.Field(i), readable)
+			continue
+		}
+
+		if tag == "-" {
+			continue
+		}
+
+		allowed := false
+		for _, name := range readable {
+			if name == tag || (tag == "" && strings.EqualFold(field.Name, name)) {
+				allowed = true
+				break
+			}
+		}
+
+		if !allowed {
+			value.Field(i).Set(reflect.Zero(field.Type))
+		}
+	}
+}
+
+func containsField(fields []string, name string) bool {
+	for _, field := range fields {
+		if field == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func eventAction(event MethodEvent) string {
+	switch event {
+	case All:
+		return "listing"
+	case Find:
+		return "viewing"
+	case Create:
+		return "creating"
+	case Update, Patch:
+		return "updating"
+	case Restore:
+		return "restoring"
+	case Delete:
+		return "deleting"
+	}
+
+	return string(event)
+}
@@ -0,0 +1,166 @@
+package restapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/miniyus/go-restapi"
+	"github.com/miniyus/gofiber/app"
+	"gorm.io/gorm"
+	"net/http/httptest"
+	"testing"
+)
+
+type testPolicy struct {
+	restapi.AllowAll[TestEntity]
+}
+
+func (testPolicy) CanView(ctx context.Context, ent *TestEntity) bool {
+	return ent.ID != 1
+}
+
+func (testPolicy) CanDelete(ctx context.Context, ent *TestEntity) bool {
+	return false
+}
+
+func (testPolicy) ListScope(ctx context.Context, db *gorm.DB) *gorm.DB {
+	return db.Where("id <> ?", 1)
+}
+
+func TestGenericService_Policy(t *testing.T) {
+	s := newTestService()
+	s.SetPolicy(testPolicy{})
+
+	if _, err := s.Find(uint(1)); !errors.Is(err, restapi.ErrForbidden) {
+		t.Errorf("expected forbidden view, got %v", err)
+	}
+
+	if _, err := s.Delete(uint(2)); !errors.Is(err, restapi.ErrForbidden) {
+		t.Errorf("expected forbidden delete, got %v", err)
+	}
+
+	all, err := s.All(&restapi.Filter[TestEntity]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, res := range all.Data {
+		if res.Id == 1 {
+			t.Error("listing returned a row outside the policy scope")
+		}
+	}
+}
+
+type fieldPolicy struct {
+	restapi.AllowAll[TestEntity]
+}
+
+func (fieldPolicy) ReadableFields(ctx context.Context) []string {
+	return []string{"id"}
+}
+
+func (fieldPolicy) WritableFields(ctx context.Context) []string {
+	return []string{"test_relation"}
+}
+
+func TestGenericService_FieldPolicy(t *testing.T) {
+	s := newTestService()
+	repo := s.Repo()
+	s.SetPolicy(fieldPolicy{})
+
+	find, err := s.Find(uint(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if find.Id != 3 || find.Name != "" {
+		t.Errorf("expected only the id to be readable, got %+v", find)
+	}
+
+	if _, err = s.Patch(uint(3), &TestReq{Name: "patched"}, "name"); !errors.Is(err, restapi.ErrForbidden) {
+		t.Errorf("expected forbidden patch, got %v", err)
+	}
+
+	before, err := repo.FindByKey(uint(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = s.Update(uint(3), &TestReq{Name: "updated"}); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := repo.FindByKey(uint(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if after.Name != before.Name {
+		t.Errorf("update wrote the read only name %q", after.Name)
+	}
+}
+
+type updatePolicy struct {
+	restapi.AllowAll[TestEntity]
+}
+
+func (updatePolicy) CanUpdate(ctx context.Context, ent *TestEntity) bool {
+	return ent.Name != "forbidden"
+}
+
+func TestGenericService_PolicyUpdatedEntity(t *testing.T) {
+	s := newTestService()
+	s.SetPolicy(updatePolicy{})
+
+	if _, err := s.Update(uint(3), &TestReq{Name: "forbidden"}); !errors.Is(err, restapi.ErrForbidden) {
+		t.Errorf("expected the updated entity to be checked, got %v", err)
+	}
+}
+
+func TestGenericHandler_FieldPolicy(t *testing.T) {
+	handler := newTestHandler()
+	handler.GetService().SetPolicy(fieldPolicy{})
+	app.App().Fiber().Get("/field-policy-tests/:id", handler.Find)
+
+	test, err := app.App().Test(httptest.NewRequest("GET", "/field-policy-tests/3", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := make(map[string]interface{})
+	if err = json.NewDecoder(test.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := body["name"]; ok || body["id"] == nil {
+		t.Errorf("expected only readable fields, got %v", body)
+	}
+
+	test, err = app.App().Test(httptest.NewRequest("GET", "/field-policy-tests/3?fields=name", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if test.StatusCode != fiber.StatusForbidden {
+		t.Errorf("expected 403 for an unreadable field, got %d", test.StatusCode)
+	}
+}
+
+type ownedPolicy struct {
+	restapi.AllowAll[TestEntity]
+}
+
+func (ownedPolicy) CanUpdate(ctx context.Context, ent *TestEntity) bool {
+	return ent.Name != ""
+}
+
+func TestGenericService_PolicyPatchedEntity(t *testing.T) {
+	s := newTestService()
+	s.SetPolicy(ownedPolicy{})
+
+	patch := &TestReq{TestRelation: TestRelationReq{Seq: 5}}
+	if _, err := s.Patch(uint(3), patch, "test_relation"); err != nil {
+		t.Errorf("expected the untouched name to be kept for the check, got %v", err)
+	}
+}
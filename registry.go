@@ -0,0 +1,141 @@
+package restapi
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/miniyus/gofiber/app"
+	"regexp"
+)
+
+var resourceName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// Resource is a handler registered in a Registry under Name.
+type Resource struct {
+	Name    string
+	Handler interface{}
+	Options []RouteOption
+	routes  func(doc *OpenAPI, opts []RouteOption) app.SubRouter
+}
+
+// RegistryHook returns route options added to those of a resource.
+type RegistryHook func(resource *Resource) []RouteOption
+
+// Registry mounts named resources under one router and serves an index of them.
+type Registry struct {
+	resources []*Resource
+	hooks     []RegistryHook
+	doc       *OpenAPI
+	mounted   bool
+}
+
+// RegistryIndex is the body of GET / of a mounted registry.
+type RegistryIndex struct {
+	Resources []ResourceLinks   `json:"resources"`
+	Links     map[string]string `json:"links,omitempty"`
+}
+
+// ResourceLinks names a resource and links to its collection and item routes.
+type ResourceLinks struct {
+	Name  string            `json:"name"`
+	Links map[string]string `json:"links"`
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{resources: make([]*Resource, 0)}
+}
+
+// Register adds handler to registry under name; its routes are mounted at /name.
+func Register[Entity interface{}, Req RequestDTO[*Entity], Res ResponseDTO[Entity]](registry *Registry, name string, handler Handler[Entity, Req, Res], opts ...RouteOption) *Resource {
+	if !resourceName.MatchString(name) {
+		panic(fmt.Sprintf("restapi: invalid resource name '%s'", name))
+	}
+
+	if registry.Resource(name) != nil {
+		panic(fmt.Sprintf("restapi: resource '%s' is already registered", name))
+	}
+
+	resource := &Resource{
+		Name:    name,
+		Handler: handler,
+		Options: opts,
+		routes: func(doc *OpenAPI, opts []RouteOption) app.SubRouter {
+			if doc != nil {
+				return Document(doc, handler, opts...)
+			}
+			return Route(handler, opts...)
+		},
+	}
+	registry.resources = append(registry.resources, resource)
+
+	return resource
+}
+
+// Resource returns the resource registered under name, nil when there is none.
+func (r *Registry) Resource(name string) *Resource {
+	for _, resource := range r.resources {
+		if resource.Name == name {
+			return resource
+		}
+	}
+
+	return nil
+}
+
+// Resources returns the registered resources in registration order.
+func (r *Registry) Resources() []*Resource {
+	return append([]*Resource{}, r.resources...)
+}
+
+// Hook adds a hook that runs for every resource when the registry is mounted.
+func (r *Registry) Hook(hook RegistryHook) {
+	r.hooks = append(r.hooks, hook)
+}
+
+// Document describes every resource in doc and serves it at /openapi.json.
+func (r *Registry) Document(doc *OpenAPI) {
+	r.doc = doc
+}
+
+// Route mounts the index at / and every resource at /name of router.
+func (r *Registry) Route(router fiber.Router) {
+	if r.mounted {
+		panic("restapi: registry is already mounted")
+	}
+	r.mounted = true
+
+	prefix := routerPrefix(router)
+	index := RegistryIndex{Resources: make([]ResourceLinks, 0, len(r.resources))}
+	if r.doc != nil {
+		index.Links = map[string]string{"openapi": prefix + "/openapi.json"}
+		r.doc.Route(router)
+	}
+
+	for _, resource := range r.resources {
+		opts := append([]RouteOption{}, resource.Options...)
+		for _, hook := range r.hooks {
+			opts = append(opts, hook(resource)...)
+		}
+
+		path := "/" + resource.Name
+		links := map[string]string{"self": prefix + path}
+		if config := newRouteConfig(opts); config.enabled(Find) {
+			links["item"] = prefix + path + "/{" + config.idParam + "}"
+		}
+		index.Resources = append(index.Resources, ResourceLinks{Name: resource.Name, Links: links})
+
+		resource.routes(r.doc, opts)(router.Group(path))
+	}
+
+	router.Get("/", func(ctx *fiber.Ctx) error {
+		return ctx.JSON(index)
+	})
+}
+
+func routerPrefix(router fiber.Router) string {
+	if group, ok := router.(*fiber.Group); ok {
+		return group.Prefix
+	}
+
+	return ""
+}
@@ -0,0 +1,70 @@
+package restapi_test
+
+import (
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/miniyus/go-restapi"
+	"github.com/miniyus/gofiber/app"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := restapi.NewRegistry()
+	restapi.Register(registry, "tests", h, restapi.ReadOnly())
+	restapi.Register(registry, "relations", restapi.NewHandler[TestRelationModel, *TestRelationReq, *TestRelationRes](
+		&TestRelationReq{},
+		restapi.NewService[TestRelationModel, *TestRelationReq, *TestRelationRes](
+			restapi.NewRepository[TestRelationModel](db, TestRelationModel{}),
+			&TestRelationRes{},
+		),
+	))
+
+	hooked := make([]string, 0)
+	registry.Hook(func(resource *restapi.Resource) []restapi.RouteOption {
+		hooked = append(hooked, resource.Name)
+		return nil
+	})
+	registry.Route(app.App().Fiber().Group("/registry-tests"))
+
+	if len(hooked) != 2 {
+		t.Errorf("expected the hook to run for every resource, got %v", hooked)
+	}
+
+	test, err := app.App().Test(httptest.NewRequest("GET", "/registry-tests", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var index restapi.RegistryIndex
+	if err = json.NewDecoder(test.Body).Decode(&index); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(index.Resources) != 2 || index.Resources[0].Links["self"] != "/registry-tests/tests" {
+		t.Errorf("unexpected index %+v", index)
+	}
+
+	test, err = app.App().Test(httptest.NewRequest("GET", "/registry-tests/tests/1", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if test.StatusCode != fiber.StatusOK {
+		t.Errorf("expected 200, got %d", test.StatusCode)
+	}
+}
+
+func TestRegister_InvalidName(t *testing.T) {
+	for _, name := range []string{"", "a/b", ":id"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected registering '%s' to panic", name)
+				}
+			}()
+
+			restapi.Register(restapi.NewRegistry(), name, h)
+		}()
+	}
+}
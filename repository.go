@@ -1,44 +1,453 @@
 package restapi
 
 import (
+	"context"
+	"errors"
 	"github.com/miniyus/gorm-extension/gormrepo"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+	"reflect"
 )
 
 type Filterable[Entity interface{}] interface {
 	GetByFilter(f *Filter[Entity]) ([]Entity, error)
+	GetByFilterContext(ctx context.Context, f *Filter[Entity]) ([]Entity, error)
+	CountByFilter(f *Filter[Entity]) (int64, error)
+}
+
+type Keyed[Entity interface{}] interface {
+	FindByKey(key interface{}) (*Entity, error)
+	UpdateByKey(key interface{}, ent Entity) (*Entity, error)
+	PatchByKey(key interface{}, ent Entity, columns []string) (*Entity, error)
+	DeleteByKey(key interface{}) (bool, error)
+}
+
+type Transactional[Entity interface{}] interface {
+	WithTx(tx *gorm.DB) Repository[Entity]
+	WithContext(ctx context.Context) Repository[Entity]
+	Transaction(fn func(repo Repository[Entity]) error) error
+}
+
+type Trashable[Entity interface{}] interface {
+	Unscoped() Repository[Entity]
+	RestoreByKey(key interface{}) (*Entity, error)
 }
 
 type Repository[Entity interface{}] interface {
 	gormrepo.GenericRepository[Entity]
 	Filterable[Entity]
+	Keyed[Entity]
+	Transactional[Entity]
+	Trashable[Entity]
+	Tenanted[Entity]
 }
 
 type GenericRepository[Entity interface{}] struct {
 	gormrepo.GenericRepository[Entity]
+	db *gorm.DB
 }
 
+// NewRepository preloads every direct association of model.
 func NewRepository[Entity interface{}](db *gorm.DB, model Entity) Repository[Entity] {
 	return &GenericRepository[Entity]{
-		gormrepo.NewGenericRepository(db, model).Preload(clause.Associations),
+		GenericRepository: gormrepo.NewGenericRepository(db, model).Preload(clause.Associations),
+		db:                db,
+	}
+}
+
+// WithTx returns a repository that runs every query on tx.
+func (repo *GenericRepository[Entity]) WithTx(tx *gorm.DB) Repository[Entity] {
+	return NewRepository[Entity](tx, repo.GenericRepository.GetModel())
+}
+
+// WithContext returns a repository whose queries run with ctx.
+func (repo *GenericRepository[Entity]) WithContext(ctx context.Context) Repository[Entity] {
+	return NewRepository[Entity](repo.db.WithContext(ctx), repo.GenericRepository.GetModel())
+}
+
+func (repo *GenericRepository[Entity]) Transaction(fn func(repo Repository[Entity]) error) error {
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		return fn(repo.WithTx(tx))
+	})
+}
+
+// Create stamps the scopes of the query context on ent before inserting it.
+func (repo *GenericRepository[Entity]) Create(ent Entity) (*Entity, error) {
+	if err := stampScopes(repo.db, &ent); err != nil {
+		return nil, err
+	}
+
+	return repo.GenericRepository.Create(ent)
+}
+
+// The methods below restrict the ones of gormrepo to the scopes.
+
+// Debug returns a repository that logs every query.
+func (repo *GenericRepository[Entity]) Debug() gormrepo.GenericRepository[Entity] {
+	return &GenericRepository[Entity]{
+		GenericRepository: repo.GenericRepository.Debug(),
+		db:                repo.db.Debug(),
+	}
+}
+
+// Preload returns a repository that also preloads query.
+func (repo *GenericRepository[Entity]) Preload(query string, args ...interface{}) gormrepo.GenericRepository[Entity] {
+	return &GenericRepository[Entity]{
+		GenericRepository: repo.GenericRepository.Preload(query, args...),
+		db:                repo.db.Preload(query, args...),
+	}
+}
+
+func (repo *GenericRepository[Entity]) All() ([]Entity, error) {
+	return repo.Get(func(tx *gorm.DB) (*gorm.DB, error) {
+		return tx, nil
+	})
+}
+
+func (repo *GenericRepository[Entity]) Update(pk uint, ent Entity) (*Entity, error) {
+	return repo.UpdateByKey(pk, ent)
+}
+
+// Save inserts ent, or updates it within the scopes when its key is set.
+func (repo *GenericRepository[Entity]) Save(ent Entity) (*Entity, error) {
+	if err := stampScopes(repo.db, &ent); err != nil {
+		return nil, err
+	}
+
+	sch, err := parseSchema(repo.db, &ent)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := len(sch.PrimaryFields) != 0
+	rv := reflect.ValueOf(&ent).Elem()
+	for _, field := range sch.PrimaryFields {
+		if _, zero := field.ValueOf(repo.db.Statement.Context, rv); zero {
+			stored = false
+		}
+	}
+
+	err = repo.db.Transaction(func(tx *gorm.DB) error {
+		if stored {
+			key, err := entityKey(tx, &ent)
+			if err != nil {
+				return err
+			}
+
+			if _, err = repo.WithTx(tx).FindByKey(key); err != nil {
+				return err
+			}
+		}
+
+		return tx.Save(&ent).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &ent, nil
+}
+
+func (repo *GenericRepository[Entity]) Find(pk uint) (*Entity, error) {
+	return repo.FindByKey(pk)
+}
+
+func (repo *GenericRepository[Entity]) FindByEntity(ent Entity) (*Entity, error) {
+	return repo.first(func(tx *gorm.DB) *gorm.DB {
+		return tx.Where(&ent)
+	})
+}
+
+func (repo *GenericRepository[Entity]) FindByAttribute(attr string, value interface{}) (*Entity, error) {
+	return repo.first(func(tx *gorm.DB) *gorm.DB {
+		return tx.Where(map[string]interface{}{attr: value})
+	})
+}
+
+// Get runs fn on a query restricted to the scopes and finds its rows.
+func (repo *GenericRepository[Entity]) Get(fn func(tx *gorm.DB) (*gorm.DB, error)) ([]Entity, error) {
+	entities := make([]Entity, 0)
+	db, err := repo.query()
+	if err != nil {
+		return entities, err
 	}
+
+	db, err = fn(db)
+	if err != nil {
+		return entities, err
+	}
+
+	err = db.Find(&entities).Error
+
+	return entities, err
+}
+
+func (repo *GenericRepository[Entity]) GetByEntity(ent Entity) ([]Entity, error) {
+	return repo.Get(func(tx *gorm.DB) (*gorm.DB, error) {
+		return tx.Where(&ent), nil
+	})
+}
+
+func (repo *GenericRepository[Entity]) GetByAttributes(attrs map[string]interface{}) ([]Entity, error) {
+	return repo.Get(func(tx *gorm.DB) (*gorm.DB, error) {
+		return tx.Where(attrs), nil
+	})
+}
+
+func (repo *GenericRepository[Entity]) Delete(pk uint) (bool, error) {
+	return repo.DeleteByKey(pk)
+}
+
+func (repo *GenericRepository[Entity]) query() (*gorm.DB, error) {
+	model := repo.GenericRepository.GetModel()
+	db, err := applyScopes(repo.db.Model(&model))
+	if err != nil {
+		return nil, err
+	}
+
+	return includeFrom(db.Statement.Context).Include(db)
+}
+
+func (repo *GenericRepository[Entity]) first(where func(tx *gorm.DB) *gorm.DB) (*Entity, error) {
+	db, err := repo.query()
+	if err != nil {
+		return nil, err
+	}
+
+	model := repo.GenericRepository.GetModel()
+	if err = where(db).First(&model).Error; err != nil {
+		return nil, err
+	}
+
+	return &model, nil
 }
 
 func (repo *GenericRepository[Entity]) GetByFilter(filter *Filter[Entity]) ([]Entity, error) {
 	entities := make([]Entity, 0)
-	db := repo.DB()
-	if filter != nil {
-		filter.SetEntity(repo.GenericRepository.GetModel())
-		search, err := filter.Search(db)
+	model := repo.GenericRepository.GetModel()
+	db, err := applyScopes(repo.db.Model(&model))
+	if err != nil {
+		return entities, err
+	}
+	db = applyListScope(db)
+
+	if filter == nil {
+		db, err = Includer{}.Include(db)
+		if err != nil {
+			return entities, err
+		}
+	} else {
+		filter.SetEntity(model)
+		scoped, err := scopeTrashed(db, filter.Trashed)
+		if err != nil {
+			return entities, err
+		}
+
+		search, err := filter.Search(scoped)
+		if err != nil {
+			return entities, err
+		}
+
+		search, err = filter.applySelect(search)
+		if err != nil {
+			return entities, err
+		}
+
+		search, err = filter.Include(search)
+		if err != nil {
+			return entities, err
+		}
+
+		if filter.Cursor != nil {
+			return repo.getByCursor(filter, search)
+		}
+
+		sort, err := filter.Sort(search)
 		if err != nil {
 			return entities, err
 		}
 
-		db = filter.Sort(search)
+		db = filter.Paginate(sort)
 	}
 
-	err := db.Find(&entities).Error
+	err = db.Find(&entities).Error
 
 	return entities, err
 }
+
+func (repo *GenericRepository[Entity]) GetByFilterContext(ctx context.Context, filter *Filter[Entity]) ([]Entity, error) {
+	return repo.WithContext(ctx).GetByFilter(filter)
+}
+
+func (repo *GenericRepository[Entity]) getByCursor(filter *Filter[Entity], db *gorm.DB) ([]Entity, error) {
+	entities := make([]Entity, 0)
+	db, err := filter.KeysetPaginate(db)
+	if err != nil {
+		return entities, err
+	}
+
+	err = db.Find(&entities).Error
+	if err != nil {
+		return entities, err
+	}
+
+	return filter.keysetResult(entities)
+}
+
+func (repo *GenericRepository[Entity]) CountByFilter(filter *Filter[Entity]) (int64, error) {
+	var total int64
+	model := repo.GenericRepository.GetModel()
+	db, err := applyScopes(repo.db.Model(&model))
+	if err != nil {
+		return total, err
+	}
+	db = applyListScope(db)
+
+	if filter != nil {
+		filter.SetEntity(model)
+		scoped, err := scopeTrashed(db, filter.Trashed)
+		if err != nil {
+			return total, err
+		}
+
+		search, err := filter.Search(scoped)
+		if err != nil {
+			return total, err
+		}
+
+		db = search
+	}
+
+	err = db.Count(&total).Error
+
+	return total, err
+}
+
+// FindByKey selects the columns and relations of WithSelect and WithInclude.
+func (repo *GenericRepository[Entity]) FindByKey(key interface{}) (*Entity, error) {
+	model := repo.GenericRepository.GetModel()
+	db, err := whereKey(repo.db.Model(&model), key)
+	if err != nil {
+		return nil, err
+	}
+
+	if columns := selectFrom(db.Statement.Context); len(columns) != 0 {
+		sch, err := parseSchema(db, &model)
+		if err != nil {
+			return nil, err
+		}
+		db = selectColumns(db, sch, columns, versionColumns(sch, &model)...)
+	}
+
+	db, err = includeFrom(db.Statement.Context).Include(db)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.First(&model).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &model, nil
+}
+
+func (repo *GenericRepository[Entity]) UpdateByKey(key interface{}, ent Entity) (*Entity, error) {
+	if err := stampScopes(repo.db, &ent); err != nil {
+		return nil, err
+	}
+
+	model := repo.GenericRepository.GetModel()
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		db, err := whereKey(tx.Model(&model), key)
+		if err != nil {
+			return err
+		}
+
+		if err = db.First(&model).Error; err != nil {
+			return err
+		}
+
+		db, version, err := lockVersion(tx.Model(&model), &model, &ent)
+		if err != nil {
+			return err
+		}
+
+		return checkUpdated(db.Updates(ent), version)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// reload, so that timestamps carry the precision the database stores
+	return repo.FindByKey(key)
+}
+
+// PatchByKey updates only the given columns, zero values included.
+func (repo *GenericRepository[Entity]) PatchByKey(key interface{}, ent Entity, columns []string) (*Entity, error) {
+	if len(columns) == 0 {
+		return repo.FindByKey(key)
+	}
+
+	if err := stampScopes(repo.db, &ent); err != nil {
+		return nil, err
+	}
+
+	model := repo.GenericRepository.GetModel()
+	err := repo.db.Transaction(func(tx *gorm.DB) error {
+		db, err := whereKey(tx.Model(&model), key)
+		if err != nil {
+			return err
+		}
+
+		if err = db.First(&model).Error; err != nil {
+			return err
+		}
+
+		db, version, err := lockVersion(tx.Model(&model), &model, &ent)
+		if err != nil {
+			return err
+		}
+
+		if version != nil && isCounter(version) {
+			columns = append(columns, version.DBName)
+		}
+
+		return checkUpdated(db.Select(columns).Updates(ent), version)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.FindByKey(key)
+}
+
+func (repo *GenericRepository[Entity]) DeleteByKey(key interface{}) (bool, error) {
+	model, err := repo.FindByKey(key)
+	if err != nil {
+		return false, err
+	}
+
+	err = repo.db.Delete(model).Error
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func checkUpdated(db *gorm.DB, version *schema.Field) error {
+	if db.Error != nil {
+		return db.Error
+	}
+
+	if version != nil && db.RowsAffected == 0 {
+		return PreconditionFailed(errors.New("resource has been modified"))
+	}
+
+	return nil
+}
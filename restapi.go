@@ -1,19 +1,166 @@
 package restapi
 
 import (
+	"errors"
 	"github.com/gofiber/fiber/v2"
 	"github.com/miniyus/gofiber/app"
 	"gorm.io/gorm"
 )
 
-func Route[Entity interface{}, Req RequestDTO[*Entity], Res ResponseDTO[Entity]](handler Handler[Entity, Req, Res]) app.SubRouter {
+// ActionFunc handles a custom action on the record identified by key.
+type ActionFunc func(ctx *fiber.Ctx, key interface{}) error
+
+type action struct {
+	method     string
+	name       string
+	fn         ActionFunc
+	middleware []fiber.Handler
+}
+
+type routeConfig struct {
+	only       map[MethodEvent]bool
+	idParam    string
+	middleware map[MethodEvent][]fiber.Handler
+	actions    []action
+}
+
+func (c *routeConfig) enabled(event MethodEvent) bool {
+	return c.only == nil || c.only[event]
+}
+
+func (c *routeConfig) handlers(event MethodEvent, handler fiber.Handler) []fiber.Handler {
+	handlers := append(append([]fiber.Handler{}, c.middleware[Common]...), c.middleware[event]...)
+	return append(handlers, handler)
+}
+
+// RouteOption customizes the routes registered by Route.
+type RouteOption func(config *routeConfig)
+
+// Only registers the routes of events and nothing else.
+func Only(events ...MethodEvent) RouteOption {
+	return func(config *routeConfig) {
+		config.only = make(map[MethodEvent]bool, len(events))
+		for _, event := range events {
+			config.only[event] = true
+		}
+	}
+}
+
+// ReadOnly registers GET / and GET /:id only.
+func ReadOnly() RouteOption {
+	return Only(All, Find)
+}
+
+// IdParam renames the :id path parameter.
+func IdParam(name string) RouteOption {
+	return func(config *routeConfig) {
+		config.idParam = name
+	}
+}
+
+// Middleware runs handlers before the routes of event.
+func Middleware(event MethodEvent, handlers ...fiber.Handler) RouteOption {
+	return func(config *routeConfig) {
+		config.middleware[event] = append(config.middleware[event], handlers...)
+	}
+}
+
+// Action registers method /:id/name.
+func Action(method string, name string, fn ActionFunc, middleware ...fiber.Handler) RouteOption {
+	return func(config *routeConfig) {
+		config.actions = append(config.actions, action{method: method, name: name, fn: fn, middleware: middleware})
+	}
+}
+
+// Route registers the CRUD and bulk routes of handler.
+func Route[Entity interface{}, Req RequestDTO[*Entity], Res ResponseDTO[Entity]](handler Handler[Entity, Req, Res], opts ...RouteOption) app.SubRouter {
+	list := routes(handler, newRouteConfig(opts))
 	return func(router fiber.Router) {
-		router.Post("/", handler.Create)
-		router.Get("/", handler.All)
-		router.Get("/:id", handler.Find)
-		router.Put("/:id", handler.Update)
-		router.Patch("/:id", handler.Patch)
-		router.Delete("/:id", handler.Delete)
+		for _, r := range list {
+			router.Add(r.method, r.path, r.handlers...)
+		}
+	}
+}
+
+type route struct {
+	event    MethodEvent
+	method   string
+	path     string
+	action   string
+	handlers []fiber.Handler
+}
+
+func newRouteConfig(opts []RouteOption) *routeConfig {
+	config := &routeConfig{idParam: "id", middleware: make(map[MethodEvent][]fiber.Handler)}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	return config
+}
+
+func routes[Entity interface{}, Req RequestDTO[*Entity], Res ResponseDTO[Entity]](handler Handler[Entity, Req, Res], config *routeConfig) []route {
+	var ent Entity
+	// the id param is resolved per route rather than set on the handler,
+	// so one handler can be mounted several times with different IdParam options
+	param := useIdParam(config.idParam)
+
+	list := make([]route, 0)
+	add := func(event MethodEvent, method string, path string, h fiber.Handler) {
+		if config.enabled(event) {
+			handlers := append([]fiber.Handler{param}, config.handlers(event, h)...)
+			list = append(list, route{event: event, method: method, path: path, handlers: handlers})
+		}
+	}
+
+	item := "/:" + config.idParam
+	add(Create, fiber.MethodPost, "/bulk", handler.BulkCreate)
+	add(Patch, fiber.MethodPatch, "/bulk", handler.BulkPatch)
+	add(Delete, fiber.MethodDelete, "/bulk", handler.BulkDelete)
+	add(Create, fiber.MethodPost, "/", handler.Create)
+	add(All, fiber.MethodGet, "/", handler.All)
+	for _, a := range config.actions {
+		handlers := append(append([]fiber.Handler{param}, config.middleware[Common]...), a.middleware...)
+		list = append(list, route{
+			event:    Common,
+			method:   a.method,
+			path:     item + "/" + a.name,
+			action:   a.name,
+			handlers: append(handlers, actionHandler(handler, a.fn)),
+		})
+	}
+	add(Find, fiber.MethodGet, item, handler.Find)
+	add(Update, fiber.MethodPut, item, handler.Update)
+	add(Patch, fiber.MethodPatch, item, handler.Patch)
+	add(Delete, fiber.MethodDelete, item, handler.Delete)
+	if HasSoftDelete(&ent) {
+		add(Restore, fiber.MethodPost, item+"/restore", handler.Restore)
+	}
+
+	return list
+}
+
+func actionHandler[Entity interface{}, Req RequestDTO[*Entity], Res ResponseDTO[Entity]](handler Handler[Entity, Req, Res], fn ActionFunc) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		key, err := handler.Key(ctx)
+		if err == nil {
+			err = fn(ctx, key)
+		}
+
+		if err != nil && !errors.Is(err, errResponded) {
+			return ErrorHandler(ctx, err)
+		}
+
+		return nil
+	}
+}
+
+type idParamKey struct{}
+
+func useIdParam(name string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		ctx.Locals(idParamKey{}, name)
+		return ctx.Next()
 	}
 }
 
@@ -22,9 +169,10 @@ func New[Entity interface{}, Req RequestDTO[*Entity], Res ResponseDTO[Entity]](
 	ent Entity,
 	req Req,
 	res Res,
+	opts ...RouteOption,
 ) app.SubRouter {
 	repo := NewRepository[Entity](db, ent)
 	service := NewService[Entity, Req, Res](repo, res)
 	handler := NewHandler[Entity, Req, Res](req, service)
-	return Route(handler)
+	return Route(handler, opts...)
 }
@@ -1,6 +1,7 @@
 package restapi_test
 
 import (
+	"github.com/gofiber/fiber/v2"
 	"github.com/miniyus/go-restapi"
 	"github.com/miniyus/gofiber/app"
 	"io"
@@ -36,3 +37,70 @@ func TestRoute(t *testing.T) {
 
 	t.Log(string(all))
 }
+
+func TestRoute_Options(t *testing.T) {
+	handler := newTestHandler()
+
+	unauthorized := func(ctx *fiber.Ctx) error {
+		return ctx.SendStatus(fiber.StatusUnauthorized)
+	}
+
+	publish := func(ctx *fiber.Ctx, key interface{}) error {
+		if key != uint(1) {
+			return restapi.NotFound(nil)
+		}
+
+		return ctx.JSON(fiber.Map{"published": key})
+	}
+
+	restapi.Route(
+		handler,
+		restapi.Only(restapi.All, restapi.Find, restapi.Patch),
+		restapi.IdParam("test_id"),
+		restapi.Middleware(restapi.Patch, unauthorized),
+		restapi.Action(fiber.MethodPost, "publish", publish),
+	)(app.App().Fiber().Group("/option-tests"))
+
+	testCases := []struct {
+		method string
+		url    string
+		status int
+	}{
+		{"GET", "/option-tests", fiber.StatusOK},
+		{"GET", "/option-tests/1", fiber.StatusOK},
+		{"GET", "/option-tests/abc", fiber.StatusBadRequest},
+		{"PATCH", "/option-tests/1", fiber.StatusUnauthorized},
+		{"DELETE", "/option-tests/1", fiber.StatusMethodNotAllowed},
+		{"POST", "/option-tests/1/publish", fiber.StatusOK},
+		{"POST", "/option-tests/2/publish", fiber.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		test, err := app.App().Test(httptest.NewRequest(tc.method, tc.url, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if test.StatusCode != tc.status {
+			t.Errorf("%s %s: expected %d, got %d", tc.method, tc.url, tc.status, test.StatusCode)
+		}
+	}
+}
+
+func TestRoute_MountTwice(t *testing.T) {
+	handler := newTestHandler()
+
+	restapi.Route(handler, restapi.ReadOnly())(app.App().Fiber().Group("/mount-tests"))
+	restapi.Route(handler, restapi.ReadOnly(), restapi.IdParam("test_id"))(app.App().Fiber().Group("/renamed-mount-tests"))
+
+	for _, url := range []string{"/mount-tests/1", "/renamed-mount-tests/1"} {
+		test, err := app.App().Test(httptest.NewRequest("GET", url, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if test.StatusCode != fiber.StatusOK {
+			t.Errorf("%s: expected %d, got %d", url, fiber.StatusOK, test.StatusCode)
+		}
+	}
+}
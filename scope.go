@@ -0,0 +1,94 @@
+package restapi
+
+import (
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"reflect"
+)
+
+// Scope restricts repository queries to rows whose Column equals Value.
+type Scope struct {
+	Column string
+	Value  interface{}
+}
+
+type scopeKey struct{}
+
+// WithScope adds scope to the scopes already carried by ctx.
+func WithScope(ctx context.Context, scope Scope) context.Context {
+	scopes := append(append([]Scope{}, scopesFrom(ctx)...), scope)
+	return context.WithValue(ctx, scopeKey{}, scopes)
+}
+
+func scopesFrom(ctx context.Context) []Scope {
+	if ctx == nil {
+		return nil
+	}
+
+	scopes, _ := ctx.Value(scopeKey{}).([]Scope)
+	return scopes
+}
+
+func scopesOf(db *gorm.DB) ([]Scope, error) {
+	scopes := scopesFrom(db.Statement.Context)
+	tenant, err := tenantScope(db)
+	if err != nil || tenant == nil {
+		return scopes, err
+	}
+
+	return append(append([]Scope{}, scopes...), *tenant), nil
+}
+
+func applyScopes(db *gorm.DB) (*gorm.DB, error) {
+	scopes, err := scopesOf(db)
+	if err != nil || len(scopes) == 0 {
+		return db, err
+	}
+
+	sch, err := parseSchema(db, db.Statement.Model)
+	if err != nil {
+		return db, err
+	}
+
+	for _, scope := range scopes {
+		field := sch.LookUpField(scope.Column)
+		if field == nil || field.DBName == "" {
+			return db, fmt.Errorf("unknown scope column '%s'", scope.Column)
+		}
+
+		db = db.Where(clause.Eq{
+			Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName},
+			Value:  scope.Value,
+		})
+	}
+
+	return db, nil
+}
+
+func stampScopes(db *gorm.DB, ent interface{}) error {
+	scopes, err := scopesOf(db)
+	if err != nil || len(scopes) == 0 {
+		return err
+	}
+
+	sch, err := parseSchema(db, ent)
+	if err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(ent).Elem()
+	for _, scope := range scopes {
+		field := sch.LookUpField(scope.Column)
+		if field == nil || field.DBName == "" {
+			return fmt.Errorf("unknown scope column '%s'", scope.Column)
+		}
+
+		if err = field.Set(db.Statement.Context, rv, scope.Value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,188 @@
+package restapi
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type Operator string
+
+const (
+	Eq    Operator = "eq"
+	Ne    Operator = "ne"
+	Gt    Operator = "gt"
+	Gte   Operator = "gte"
+	Lt    Operator = "lt"
+	Lte   Operator = "lte"
+	Like  Operator = "like"
+	In    Operator = "in"
+	NotIn Operator = "nin"
+	Null  Operator = "null"
+)
+
+var operators = map[Operator]bool{
+	Eq: true, Ne: true, Gt: true, Gte: true, Lt: true, Lte: true,
+	Like: true, In: true, NotIn: true, Null: true,
+}
+
+var conditionKey = regexp.MustCompile(`^([A-Za-z0-9_]+)\[([a-z]+)]$`)
+
+var schemaCaches = &sync.Map{}
+
+// Searchable is implemented by request DTOs that allow operator filters.
+type Searchable interface {
+	SearchColumns() []string
+}
+
+type Condition struct {
+	Column   string
+	Operator Operator
+	Value    string
+}
+
+// ParseConditions reads column[operator]=value pairs from the query string.
+func ParseConditions(ctx *fiber.Ctx, columns []string) ([]Condition, error) {
+	allowed := make(map[string]bool, len(columns))
+	for _, col := range columns {
+		allowed[col] = true
+	}
+
+	conditions := make([]Condition, 0)
+	var err error
+	ctx.Context().QueryArgs().VisitAll(func(key, value []byte) {
+		if err != nil {
+			return
+		}
+
+		match := conditionKey.FindStringSubmatch(string(key))
+		if match == nil {
+			return
+		}
+
+		col, op := match[1], Operator(match[2])
+		if !allowed[col] {
+			err = BadRequest(fmt.Errorf("filter on column '%s' is not allowed", col))
+			return
+		}
+
+		if !operators[op] {
+			err = BadRequest(fmt.Errorf("unknown filter operator '%s'", op))
+			return
+		}
+
+		conditions = append(conditions, Condition{
+			Column:   col,
+			Operator: op,
+			Value:    string(value),
+		})
+	})
+
+	return conditions, err
+}
+
+func (c Condition) Apply(db *gorm.DB, sch *schema.Schema) (*gorm.DB, error) {
+	field := sch.LookUpField(c.Column)
+	if field == nil || field.DBName == "" {
+		return db, BadRequest(fmt.Errorf("unknown column '%s'", c.Column))
+	}
+
+	col := clause.Column{Table: clause.CurrentTable, Name: field.DBName}
+
+	if c.Operator == Null {
+		isNull, err := strconv.ParseBool(c.Value)
+		if err != nil {
+			return db, BadRequest(fmt.Errorf("%s[null]: %w", c.Column, err))
+		}
+
+		if isNull {
+			return db.Where(clause.Eq{Column: col, Value: nil}), nil
+		}
+		return db.Where(clause.Neq{Column: col, Value: nil}), nil
+	}
+
+	if c.Operator == In || c.Operator == NotIn {
+		values := make([]interface{}, 0)
+		for _, raw := range strings.Split(c.Value, ",") {
+			v, err := convertValue(field.FieldType, raw)
+			if err != nil {
+				return db, BadRequest(fmt.Errorf("%s[%s]: %w", c.Column, c.Operator, err))
+			}
+			values = append(values, v)
+		}
+
+		if c.Operator == In {
+			return db.Where(clause.IN{Column: col, Values: values}), nil
+		}
+		return db.Where(clause.Not(clause.IN{Column: col, Values: values})), nil
+	}
+
+	if c.Operator == Like {
+		return db.Where(clause.Like{Column: col, Value: c.Value}), nil
+	}
+
+	v, err := convertValue(field.FieldType, c.Value)
+	if err != nil {
+		return db, BadRequest(fmt.Errorf("%s[%s]: %w", c.Column, c.Operator, err))
+	}
+
+	switch c.Operator {
+	case Eq:
+		return db.Where(clause.Eq{Column: col, Value: v}), nil
+	case Ne:
+		return db.Where(clause.Neq{Column: col, Value: v}), nil
+	case Gt:
+		return db.Where(clause.Gt{Column: col, Value: v}), nil
+	case Gte:
+		return db.Where(clause.Gte{Column: col, Value: v}), nil
+	case Lt:
+		return db.Where(clause.Lt{Column: col, Value: v}), nil
+	case Lte:
+		return db.Where(clause.Lte{Column: col, Value: v}), nil
+	}
+
+	return db, BadRequest(fmt.Errorf("unknown filter operator '%s'", c.Operator))
+}
+
+func convertValue(ty reflect.Type, raw string) (interface{}, error) {
+	for ty.Kind() == reflect.Ptr {
+		ty = ty.Elem()
+	}
+
+	switch ty.Kind() {
+	case reflect.Bool:
+		return strconv.ParseBool(raw)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.ParseInt(raw, 10, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.ParseUint(raw, 10, 64)
+	case reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(raw, 64)
+	}
+
+	return raw, nil
+}
+
+func parseSchema(db *gorm.DB, model interface{}) (*schema.Schema, error) {
+	return parseSchemaWith(model, db.NamingStrategy)
+}
+
+func parseModel(model interface{}) (*schema.Schema, error) {
+	return parseSchemaWith(model, schema.NamingStrategy{})
+}
+
+func parseSchemaWith(model interface{}, namer schema.Namer) (*schema.Schema, error) {
+	if namer == nil || !reflect.TypeOf(namer).Comparable() {
+		return schema.Parse(model, &sync.Map{}, namer)
+	}
+
+	cache, _ := schemaCaches.LoadOrStore(namer, &sync.Map{})
+	return schema.Parse(model, cache.(*sync.Map), namer)
+}
@@ -0,0 +1,47 @@
+package restapi_test
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/miniyus/go-restapi"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseConditions(t *testing.T) {
+	f := fiber.New()
+	f.Get("/", func(ctx *fiber.Ctx) error {
+		conditions, err := restapi.ParseConditions(ctx, []string{"age", "name", "status"})
+		if err != nil {
+			return err
+		}
+
+		if len(conditions) != 3 {
+			t.Errorf("expected 3 conditions, got %d", len(conditions))
+		}
+
+		for _, cond := range conditions {
+			t.Logf("%s %s %s", cond.Column, cond.Operator, cond.Value)
+		}
+
+		return nil
+	})
+	f.Get("/denied", func(ctx *fiber.Ctx) error {
+		_, err := restapi.ParseConditions(ctx, []string{"name"})
+		if !errors.Is(err, restapi.ErrBadRequest) {
+			t.Errorf("expected bad request, got %v", err)
+		}
+
+		return nil
+	})
+
+	req := httptest.NewRequest("GET", "/?age[gte]=18&name[like]=jo%25&status[in]=a,b&page=1", nil)
+	if _, err := f.Test(req); err != nil {
+		t.Error(err)
+	}
+
+	req = httptest.NewRequest("GET", "/denied?age[gte]=18", nil)
+	if _, err := f.Test(req); err != nil {
+		t.Error(err)
+	}
+}
@@ -1,30 +1,58 @@
 package restapi
 
+import (
+	"context"
+	"gorm.io/gorm"
+)
+
 type Service[Entity interface{}, Req RequestDTO[*Entity], Res ResponseDTO[Entity]] interface {
-	All(filter *Filter[Entity]) ([]Res, error)
-	Find(pk uint) (Res, error)
+	All(filter *Filter[Entity]) (*Paginated[Res], error)
+	Find(pk interface{}) (Res, error)
 	Create(dto Req) (Res, error)
-	Update(pk uint, dto Req) (Res, error)
-	Patch(pk uint, dto Req) (Res, error)
-	Delete(pk uint) (bool, error)
+	Update(pk interface{}, dto Req) (Res, error)
+	Patch(pk interface{}, dto Req, fields ...string) (Res, error)
+	Delete(pk interface{}) (Res, error)
+	AllContext(ctx context.Context, filter *Filter[Entity]) (*Paginated[Res], error)
+	FindContext(ctx context.Context, pk interface{}) (Res, error)
+	CreateContext(ctx context.Context, dto Req) (Res, error)
+	UpdateContext(ctx context.Context, pk interface{}, dto Req) (Res, error)
+	PatchContext(ctx context.Context, pk interface{}, dto Req, fields ...string) (Res, error)
+	DeleteContext(ctx context.Context, pk interface{}) (Res, error)
+	Restore(pk interface{}) (Res, error)
+	RestoreContext(ctx context.Context, pk interface{}) (Res, error)
+	ForceDelete(pk interface{}) (Res, error)
+	ForceDeleteContext(ctx context.Context, pk interface{}) (Res, error)
+	BulkCreate(ctx context.Context, dtos []Req, mode BulkMode) (*BulkResult[Res], error)
+	BulkPatch(ctx context.Context, items []BulkPatch[Req], mode BulkMode) (*BulkResult[Res], error)
+	BulkDelete(ctx context.Context, keys []interface{}, mode BulkMode) (*BulkResult[Res], error)
 	Repo() Repository[Entity]
 	Response() Res
+	SetPolicy(policy Policy[Entity])
+	Policy() Policy[Entity]
 	ServiceHook[Entity, Req, Res]
 }
 
 type GenericService[Entity interface{}, Req RequestDTO[*Entity], Res ResponseDTO[Entity]] struct {
 	repo   Repository[Entity]
-	res    Res
+	newRes DTOFactory[Res]
+	policy Policy[Entity]
 	events *HasServiceEvent[Entity, Req, Res]
 }
 
 func NewService[Entity interface{}, Req RequestDTO[*Entity], Res ResponseDTO[Entity]](
 	repo Repository[Entity],
 	resDto Res,
+) Service[Entity, Req, Res] {
+	return NewServiceWithFactory[Entity, Req, Res](repo, NewDTOFactory(resDto))
+}
+
+func NewServiceWithFactory[Entity interface{}, Req RequestDTO[*Entity], Res ResponseDTO[Entity]](
+	repo Repository[Entity],
+	newRes DTOFactory[Res],
 ) Service[Entity, Req, Res] {
 	return &GenericService[Entity, Req, Res]{
 		repo:   repo,
-		res:    resDto,
+		newRes: newRes,
 		events: &HasServiceEvent[Entity, Req, Res]{&HasMethodEvent[Entity, Req, Res]{methodEvent: nil}},
 	}
 }
@@ -34,33 +62,110 @@ func (s *GenericService[Entity, Req, Res]) Repo() Repository[Entity] {
 }
 
 func (s *GenericService[Entity, Req, Res]) Response() Res {
-	return s.res
+	return s.newRes()
+}
+
+func (s *GenericService[Entity, Req, Res]) All(filter *Filter[Entity]) (*Paginated[Res], error) {
+	return s.AllContext(context.Background(), filter)
 }
 
-func (s *GenericService[Entity, Req, Res]) All(filter *Filter[Entity]) ([]Res, error) {
+// AllContext runs the listing queries with ctx.
+func (s *GenericService[Entity, Req, Res]) AllContext(ctx context.Context, filter *Filter[Entity]) (*Paginated[Res], error) {
+	if err := s.authorize(ctx, All, nil); err != nil {
+		return nil, err
+	}
+
+	if s.policy != nil {
+		ctx = withListScope(ctx, func(db *gorm.DB) *gorm.DB {
+			return s.policy.ListScope(ctx, db)
+		})
+	}
+
+	repo := s.repo.WithContext(ctx)
+	var dto Req
+	if filter != nil {
+		dto, _ = filter.req.(Req)
+	}
 
-	entities, err := s.repo.GetByFilter(filter)
+	err := s.beforeCallRepo(All, repo, dto, repo.GetModel())
 	if err != nil {
 		return nil, err
 	}
 
+	var entities []Entity
+	err = s.aroundCallRepo(All, repo, repo.GetModel(), func() (err error) {
+		entities, err = repo.GetByFilter(filter)
+		return err
+	})
+	if err != nil {
+		return nil, TranslateError(err)
+	}
+
+	// keyset pages skip the count, which is what makes them cheap
+	var total int64
+	if filter == nil || filter.Cursor == nil {
+		total, err = repo.CountByFilter(filter)
+		if err != nil {
+			return nil, TranslateError(err)
+		}
+	}
+
 	res := make([]Res, 0)
 	for _, ent := range entities {
-		temp := DeepCopy(s.res).(Res)
+		temp := s.newRes()
 		err = temp.FromEntity(ent)
 		if err != nil {
 			return nil, err
 		}
+
+		temp, err = s.respond(All, repo, temp, ent)
+		if err != nil {
+			return nil, err
+		}
 		res = append(res, temp)
 	}
 
-	return res, nil
+	if filter == nil {
+		filter = &Filter[Entity]{}
+	}
+
+	if filter.Cursor != nil {
+		return NewKeysetPaginated(res, filter.pageSize(), filter.Cursor), nil
+	}
+
+	page := filter.Page
+	page.PageSize = filter.pageSize()
+	return NewPaginated(res, total, page), nil
+}
+
+func (s *GenericService[Entity, Req, Res]) Find(pk interface{}) (Res, error) {
+	return s.FindContext(context.Background(), pk)
 }
 
-func (s *GenericService[Entity, Req, Res]) Find(pk uint) (Res, error) {
-	res := s.res
-	entity, err := s.repo.Find(pk)
-	//entity, err := s.repo.Find(pk)
+func (s *GenericService[Entity, Req, Res]) FindContext(ctx context.Context, pk interface{}) (Res, error) {
+	repo := s.repo.WithContext(ctx)
+	res := s.newRes()
+	var dto Req
+	err := s.beforeCallRepo(Find, repo, dto, repo.GetModel())
+	if err != nil {
+		return res, err
+	}
+
+	var entity *Entity
+	err = s.aroundCallRepo(Find, repo, repo.GetModel(), func() (err error) {
+		entity, err = repo.FindByKey(pk)
+		return err
+	})
+	if err != nil {
+		return res, TranslateError(err)
+	}
+
+	err = s.authorize(ctx, Find, entity)
+	if err != nil {
+		return res, err
+	}
+
+	err = recordVersion(repo.DB(), entity)
 	if err != nil {
 		return res, err
 	}
@@ -70,103 +175,259 @@ func (s *GenericService[Entity, Req, Res]) Find(pk uint) (Res, error) {
 		return res, err
 	}
 
-	return res, nil
+	return s.respond(Find, repo, res, *entity)
 }
 
+// Create runs the repository call and its hooks in one transaction.
 func (s *GenericService[Entity, Req, Res]) Create(dto Req) (Res, error) {
-	res := s.res
-	ent := s.repo.GetModel()
+	return s.CreateContext(context.Background(), dto)
+}
+
+func (s *GenericService[Entity, Req, Res]) CreateContext(ctx context.Context, dto Req) (Res, error) {
+	var res Res
+	err := s.repo.WithContext(ctx).Transaction(func(repo Repository[Entity]) (err error) {
+		res, err = s.create(repo, dto)
+		return err
+	})
+
+	return res, err
+}
+
+func (s *GenericService[Entity, Req, Res]) Update(pk interface{}, dto Req) (Res, error) {
+	return s.UpdateContext(context.Background(), pk, dto)
+}
+
+// UpdateContext fails with ErrPreconditionFailed when the If-Match value of ctx is stale.
+func (s *GenericService[Entity, Req, Res]) UpdateContext(ctx context.Context, pk interface{}, dto Req) (Res, error) {
+	var res Res
+	err := s.repo.WithContext(ctx).Transaction(func(repo Repository[Entity]) (err error) {
+		res, err = s.update(repo, pk, dto)
+		return err
+	})
+
+	return res, err
+}
+
+// Patch updates only the columns behind fields, every column when fields is nil.
+func (s *GenericService[Entity, Req, Res]) Patch(pk interface{}, dto Req, fields ...string) (Res, error) {
+	return s.PatchContext(context.Background(), pk, dto, fields...)
+}
+
+func (s *GenericService[Entity, Req, Res]) PatchContext(ctx context.Context, pk interface{}, dto Req, fields ...string) (Res, error) {
+	var res Res
+	err := s.repo.WithContext(ctx).Transaction(func(repo Repository[Entity]) (err error) {
+		res, err = s.patch(repo, pk, dto, fields)
+		return err
+	})
+
+	return res, err
+}
+
+// Delete returns the response of the record as it was before deletion.
+func (s *GenericService[Entity, Req, Res]) Delete(pk interface{}) (Res, error) {
+	return s.DeleteContext(context.Background(), pk)
+}
+
+func (s *GenericService[Entity, Req, Res]) DeleteContext(ctx context.Context, pk interface{}) (Res, error) {
+	var res Res
+	err := s.repo.WithContext(ctx).Transaction(func(repo Repository[Entity]) (err error) {
+		res, err = s.delete(repo, pk)
+		return err
+	})
+
+	return res, err
+}
+
+// Restore brings back a soft deleted record, firing the Restore hooks.
+func (s *GenericService[Entity, Req, Res]) Restore(pk interface{}) (Res, error) {
+	return s.RestoreContext(context.Background(), pk)
+}
+
+func (s *GenericService[Entity, Req, Res]) RestoreContext(ctx context.Context, pk interface{}) (Res, error) {
+	var res Res
+	err := s.repo.WithContext(ctx).Transaction(func(repo Repository[Entity]) (err error) {
+		res, err = s.restore(repo, pk)
+		return err
+	})
+
+	return res, err
+}
+
+// ForceDelete permanently deletes a record, trashed or not.
+func (s *GenericService[Entity, Req, Res]) ForceDelete(pk interface{}) (Res, error) {
+	return s.ForceDeleteContext(context.Background(), pk)
+}
+
+func (s *GenericService[Entity, Req, Res]) ForceDeleteContext(ctx context.Context, pk interface{}) (Res, error) {
+	var res Res
+	err := s.repo.WithContext(ctx).Unscoped().Transaction(func(repo Repository[Entity]) (err error) {
+		res, err = s.delete(repo, pk)
+		return err
+	})
+
+	return res, err
+}
+
+func (s *GenericService[Entity, Req, Res]) create(repo Repository[Entity], dto Req) (Res, error) {
+	res := s.newRes()
+	ent := repo.GetModel()
 	err := dto.ToEntity(&ent)
 	if err != nil {
 		return res, err
 	}
 
-	if s.features(Create).beforeCallRepo != nil {
-		err = s.features(Create).beforeCallRepo.handler(dto, ent)
-		if err != nil {
-			return res, err
-		}
+	base := repo.GetModel()
+	err = s.restrictWrite(repo, dto, &ent, &base)
+	if err != nil {
+		return res, err
 	}
 
-	create, err := s.repo.Create(ent)
+	err = s.authorize(repo.DB().Statement.Context, Create, &ent)
 	if err != nil {
 		return res, err
 	}
 
-	err = res.FromEntity(*create)
+	err = s.beforeCallRepo(Create, repo, dto, ent)
 	if err != nil {
 		return res, err
 	}
 
-	if s.features(Create).afterCallRepo != nil {
-		err = s.features(Create).afterCallRepo.handler(res, *create)
-		if err != nil {
-			return res, err
-		}
+	var create *Entity
+	err = s.aroundCallRepo(Create, repo, ent, func() (err error) {
+		create, err = repo.Create(ent)
+		return err
+	})
+	if err != nil {
+		return res, TranslateError(err)
+	}
+
+	err = res.FromEntity(*create)
+	if err != nil {
+		return res, err
 	}
 
-	return res, nil
+	return s.respond(Create, repo, res, *create)
 }
 
-func (s *GenericService[Entity, Req, Res]) Update(pk uint, dto Req) (Res, error) {
-	res := s.res
-	find, err := s.repo.Find(pk)
+func (s *GenericService[Entity, Req, Res]) update(repo Repository[Entity], pk interface{}, dto Req) (Res, error) {
+	res := s.newRes()
+	find, err := repo.FindByKey(pk)
+	if err != nil {
+		return res, TranslateError(err)
+	}
+
+	err = s.authorize(repo.DB().Statement.Context, Update, find)
 	if err != nil {
 		return res, err
 	}
 
+	err = checkIfMatch(repo.DB(), find)
+	if err != nil {
+		return res, err
+	}
+
+	stored := *find
 	err = dto.ToEntity(find)
 	if err != nil {
 		return res, err
 	}
 
-	if s.features(Update).beforeCallRepo != nil {
-		err = s.features(Update).beforeCallRepo.handler(dto, *find)
-		if err != nil {
-			return res, err
-		}
+	err = s.restrictWrite(repo, dto, find, &stored)
+	if err != nil {
+		return res, err
 	}
 
-	update, err := s.repo.Update(pk, *find)
+	// the new state must be allowed as well as the old one
+	err = s.authorize(repo.DB().Statement.Context, Update, find)
 	if err != nil {
 		return res, err
 	}
 
-	err = res.FromEntity(*update)
+	err = s.beforeCallRepo(Update, repo, dto, *find)
 	if err != nil {
 		return res, err
 	}
 
-	if s.features(Update).afterCallRepo != nil {
-		err = s.features(Update).afterCallRepo.handler(res, *update)
-		if err != nil {
-			return res, err
-		}
+	var update *Entity
+	err = s.aroundCallRepo(Update, repo, *find, func() (err error) {
+		update, err = repo.UpdateByKey(pk, *find)
+		return err
+	})
+	if err != nil {
+		return res, TranslateError(err)
 	}
 
-	return res, nil
+	err = recordVersion(repo.DB(), update)
+	if err != nil {
+		return res, err
+	}
+
+	err = res.FromEntity(*update)
+	if err != nil {
+		return res, err
+	}
+
+	return s.respond(Update, repo, res, *update)
 }
 
-func (s *GenericService[Entity, Req, Res]) Patch(pk uint, dto Req) (Res, error) {
-	res := s.res
-	find, err := s.repo.Find(pk)
+func (s *GenericService[Entity, Req, Res]) patch(repo Repository[Entity], pk interface{}, dto Req, fields []string) (Res, error) {
+	res := s.newRes()
+	err := checkWritable(fields, s.writableFields(repo.DB().Statement.Context))
+	if err != nil {
+		return res, err
+	}
+
+	find, err := repo.FindByKey(pk)
+	if err != nil {
+		return res, TranslateError(err)
+	}
+
+	err = s.authorize(repo.DB().Statement.Context, Update, find)
 	if err != nil {
 		return res, err
 	}
 
+	err = checkIfMatch(repo.DB(), find)
+	if err != nil {
+		return res, err
+	}
+
+	stored := *find
 	err = dto.ToEntity(find)
 	if err != nil {
 		return res, err
 	}
 
-	if s.features(Patch).beforeCallRepo != nil {
-		err = s.features(Patch).beforeCallRepo.handler(dto, *find)
-		if err != nil {
-			return res, err
+	patched, columns, err := s.mergePatch(repo, dto, stored, find, fields)
+	if err != nil {
+		return res, err
+	}
+
+	// the new state must be allowed as well as the old one
+	err = s.authorize(repo.DB().Statement.Context, Update, patched)
+	if err != nil {
+		return res, err
+	}
+
+	err = s.beforeCallRepo(Patch, repo, dto, *patched)
+	if err != nil {
+		return res, err
+	}
+
+	var update *Entity
+	err = s.aroundCallRepo(Patch, repo, *patched, func() (err error) {
+		if fields == nil {
+			update, err = repo.UpdateByKey(pk, *patched)
+		} else {
+			update, err = repo.PatchByKey(pk, *patched, columns)
 		}
+		return err
+	})
+	if err != nil {
+		return res, TranslateError(err)
 	}
 
-	update, err := s.repo.Update(pk, *find)
+	err = recordVersion(repo.DB(), update)
 	if err != nil {
 		return res, err
 	}
@@ -176,28 +437,153 @@ func (s *GenericService[Entity, Req, Res]) Patch(pk uint, dto Req) (Res, error)
 		return res, err
 	}
 
-	if s.features(Patch).afterCallRepo != nil {
-		err = s.features(Patch).afterCallRepo.handler(res, *update)
-		if err != nil {
-			return res, err
-		}
+	return s.respond(Patch, repo, res, *update)
+}
+
+func (s *GenericService[Entity, Req, Res]) delete(repo Repository[Entity], pk interface{}) (Res, error) {
+	res := s.newRes()
+	entity, err := repo.FindByKey(pk)
+	if err != nil {
+		return res, TranslateError(err)
+	}
+
+	err = s.authorize(repo.DB().Statement.Context, Delete, entity)
+	if err != nil {
+		return res, err
+	}
+
+	err = checkIfMatch(repo.DB(), entity)
+	if err != nil {
+		return res, err
+	}
+
+	var dto Req
+	err = s.beforeCallRepo(Delete, repo, dto, *entity)
+	if err != nil {
+		return res, err
+	}
+
+	err = s.aroundCallRepo(Delete, repo, *entity, func() (err error) {
+		_, err = repo.DeleteByKey(pk)
+		return err
+	})
+	if err != nil {
+		return res, TranslateError(err)
 	}
 
-	return res, nil
+	err = res.FromEntity(*entity)
+	if err != nil {
+		return res, err
+	}
+
+	return s.respond(Delete, repo, res, *entity)
+}
+
+func (s *GenericService[Entity, Req, Res]) restore(repo Repository[Entity], pk interface{}) (Res, error) {
+	res := s.newRes()
+	entity, err := repo.Unscoped().FindByKey(pk)
+	if err != nil {
+		return res, TranslateError(err)
+	}
+
+	err = s.authorize(repo.DB().Statement.Context, Restore, entity)
+	if err != nil {
+		return res, err
+	}
+
+	var dto Req
+	err = s.beforeCallRepo(Restore, repo, dto, *entity)
+	if err != nil {
+		return res, err
+	}
+
+	var restore *Entity
+	err = s.aroundCallRepo(Restore, repo, *entity, func() (err error) {
+		restore, err = repo.RestoreByKey(pk)
+		return err
+	})
+	if err != nil {
+		return res, TranslateError(err)
+	}
+
+	err = res.FromEntity(*restore)
+	if err != nil {
+		return res, err
+	}
+
+	return s.respond(Restore, repo, res, *restore)
 }
 
-func (s *GenericService[Entity, Req, Res]) Delete(pk uint) (bool, error) {
-	b, err := s.repo.Delete(pk)
+func (s *GenericService[Entity, Req, Res]) mergePatch(repo Repository[Entity], dto Req, stored Entity, ent *Entity, fields []string) (*Entity, []string, error) {
+	if fields == nil {
+		return ent, nil, s.restrictWrite(repo, dto, ent, &stored)
+	}
+
+	sch, err := parseSchema(repo.DB(), ent)
 	if err != nil {
-		return false, err
+		return nil, nil, err
 	}
-	return b, nil
+
+	columns, err := patchColumns(dto, fields, sch)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mergeColumns(sch, &stored, ent, columns)
+	return &stored, columns, nil
 }
 
 func (s *GenericService[Entity, Req, Res]) Hook() *HasServiceEvent[Entity, Req, Res] {
 	return s.events
 }
 
+func (s *GenericService[Entity, Req, Res]) beforeCallRepo(event MethodEvent, repo Repository[Entity], dto Req, entity Entity) error {
+	for _, ev := range []MethodEvent{Common, event} {
+		for _, hook := range s.features(ev).beforeCallRepo {
+			if err := hook.handler(repo, dto, entity); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *GenericService[Entity, Req, Res]) afterCallRepo(event MethodEvent, repo Repository[Entity], res Res, entity Entity) error {
+	for _, ev := range []MethodEvent{Common, event} {
+		for _, hook := range s.features(ev).afterCallRepo {
+			if err := hook.handler(repo, res, entity); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *GenericService[Entity, Req, Res]) aroundCallRepo(event MethodEvent, repo Repository[Entity], entity Entity, call func() error) error {
+	hooks := append(append([]*AroundCallRepo[Entity]{}, s.features(Common).aroundCallRepo...), s.features(event).aroundCallRepo...)
+	called := false
+	next := func() error {
+		called = true
+		return call()
+	}
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hook, inner := hooks[i], next
+		next = func() error {
+			return hook.handler(repo, entity, inner)
+		}
+	}
+
+	err := next()
+	if err == nil && !called {
+		return ErrRepoCallSkipped
+	}
+
+	return err
+}
+
 func (s *GenericService[Entity, Req, Res]) features(event MethodEvent) *Features[Entity, Req, Res] {
 	return s.events.getMethodEvent(event)
 }
@@ -1,7 +1,10 @@
 package restapi_test
 
 import (
+	"context"
+	"errors"
 	"github.com/miniyus/go-restapi"
+	"gorm.io/gorm"
 	"testing"
 )
 
@@ -41,7 +44,130 @@ func TestGenericService_All(t *testing.T) {
 		t.Error(err)
 	}
 
-	for _, res := range all {
+	if all.Total != int64(len(all.Data)) {
+		t.Errorf("total mismatch: total: %d, data: %d", all.Total, len(all.Data))
+	}
+
+	for _, res := range all.Data {
 		t.Logf("%v", res)
 	}
 }
+
+func TestGenericService_AllPaginate(t *testing.T) {
+	filter := restapi.Filter[TestEntity]{}
+	filter.Page.Page = 2
+	filter.Page.PageSize = 3
+	all, err := service.All(&filter)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if len(all.Data) > filter.Page.PageSize {
+		t.Errorf("page size exceeded: %d", len(all.Data))
+	}
+
+	if all.TotalPages != int((all.Total+2)/3) {
+		t.Errorf("total pages mismatch: %d", all.TotalPages)
+	}
+
+	t.Log(all)
+}
+
+func TestGenericService_DeleteResponse(t *testing.T) {
+	s := newTestService()
+	data := makeFakeData(1)[0]
+	create, err := s.Create(&data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deleted, err := s.Delete(create.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if deleted.Id != create.Id || deleted.Name != data.Name {
+		t.Errorf("expected the deleted record, got %+v", deleted)
+	}
+}
+
+func TestGenericService_DeleteVeto(t *testing.T) {
+	s := newTestService()
+	s.Hook().Delete().BeforeCallRepo(func(repo restapi.Repository[TestEntity], dto *TestReq, entity TestEntity) error {
+		if entity.ID == 1 {
+			return restapi.Forbidden(errors.New("protected record"))
+		}
+		return nil
+	})
+
+	var found []uint
+	s.Hook().Find().AfterCallRepo(func(repo restapi.Repository[TestEntity], dto *TestRes, entity TestEntity) error {
+		found = append(found, entity.ID)
+		return nil
+	})
+
+	_, err := s.Delete(uint(1))
+	if !errors.Is(err, restapi.ErrForbidden) {
+		t.Errorf("expected forbidden, got %v", err)
+	}
+
+	res, err := s.Find(uint(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Id != 1 || len(found) != 1 {
+		t.Errorf("find hook not fired: %v", found)
+	}
+}
+
+func TestGenericService_CreateRollback(t *testing.T) {
+	s := newTestService()
+	repo := s.Repo()
+	s.Hook().Create().AfterCallRepo(func(repo restapi.Repository[TestEntity], dto *TestRes, entity TestEntity) error {
+		_, err := repo.Create(TestEntity{Name: "side-effect-" + entity.Name})
+		if err != nil {
+			return err
+		}
+		return errors.New("after hook failed")
+	})
+
+	data := makeFakeData(1)[0]
+	_, err := s.Create(&data)
+	if err == nil {
+		t.Fatal("expected after hook error")
+	}
+
+	for _, name := range []string{data.Name, "side-effect-" + data.Name} {
+		_, err = repo.FindByAttribute("name", name)
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("%s: expected rollback, got %v", name, err)
+		}
+	}
+}
+
+func TestGenericService_FindContext(t *testing.T) {
+	s := newTestService()
+
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "trace")
+	var seen interface{}
+	s.Hook().Find().BeforeCallRepo(func(repo restapi.Repository[TestEntity], dto *TestReq, entity TestEntity) error {
+		seen = repo.DB().Statement.Context.Value(ctxKey{})
+		return nil
+	})
+
+	if _, err := s.FindContext(ctx, uint(1)); err != nil {
+		t.Error(err)
+	}
+
+	if seen != "trace" {
+		t.Errorf("context not propagated to repository: %v", seen)
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := s.FindContext(cancelled, uint(1)); err == nil {
+		t.Error("expected error for cancelled context")
+	}
+}
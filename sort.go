@@ -0,0 +1,88 @@
+package restapi
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+	"strings"
+)
+
+// Sortable is implemented by request DTOs that restrict ?sort=.
+type Sortable interface {
+	SortColumns() []string
+}
+
+type SortColumn struct {
+	Column string
+	Desc   bool
+}
+
+// ParseSort reads the ?sort=-created_at,name syntax.
+func ParseSort(param string) []SortColumn {
+	columns := make([]SortColumn, 0)
+	for _, col := range strings.Split(param, ",") {
+		col = strings.TrimSpace(col)
+		desc := strings.HasPrefix(col, "-")
+		col = strings.TrimLeft(col, "+-")
+		if col == "" {
+			continue
+		}
+
+		columns = append(columns, SortColumn{Column: col, Desc: desc})
+	}
+
+	return columns
+}
+
+func resolveColumn(db *gorm.DB, sch *schema.Schema, name string) (*gorm.DB, clause.Column, error) {
+	relName, colName, nested := strings.Cut(name, ".")
+	if !nested {
+		field := sch.LookUpField(name)
+		if field == nil || field.DBName == "" {
+			return db, clause.Column{}, BadRequest(fmt.Errorf("unknown sort column '%s'", name))
+		}
+
+		return db, clause.Column{Table: clause.CurrentTable, Name: field.DBName}, nil
+	}
+
+	rel := lookUpRelation(db, sch, relName)
+	if rel == nil || (rel.Type != schema.HasOne && rel.Type != schema.BelongsTo) {
+		return db, clause.Column{}, BadRequest(fmt.Errorf("unknown sort relation '%s'", relName))
+	}
+
+	field := rel.FieldSchema.LookUpField(colName)
+	if field == nil || field.DBName == "" {
+		return db, clause.Column{}, BadRequest(fmt.Errorf("unknown sort column '%s'", name))
+	}
+
+	if !joined(db, rel.Name) {
+		db = db.Joins(rel.Name)
+	}
+
+	return db, clause.Column{Table: rel.Name, Name: field.DBName}, nil
+}
+
+func lookUpRelation(db *gorm.DB, sch *schema.Schema, name string) *schema.Relationship {
+	if rel, ok := sch.Relationships.Relations[name]; ok {
+		return rel
+	}
+
+	for _, rel := range sch.Relationships.Relations {
+		if db.NamingStrategy.ColumnName("", rel.Name) == name {
+			return rel
+		}
+	}
+
+	return nil
+}
+
+func joined(db *gorm.DB, name string) bool {
+	for _, join := range db.Statement.Joins {
+		if join.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
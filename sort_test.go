@@ -0,0 +1,20 @@
+package restapi_test
+
+import (
+	"github.com/miniyus/go-restapi"
+	"reflect"
+	"testing"
+)
+
+func TestParseSort(t *testing.T) {
+	columns := restapi.ParseSort("-created_at, name,,+test_relation_model.seq")
+	expected := []restapi.SortColumn{
+		{Column: "created_at", Desc: true},
+		{Column: "name", Desc: false},
+		{Column: "test_relation_model.seq", Desc: false},
+	}
+
+	if !reflect.DeepEqual(columns, expected) {
+		t.Errorf("expected %v, got %v", expected, columns)
+	}
+}
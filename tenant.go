@@ -0,0 +1,46 @@
+package restapi
+
+import (
+	"context"
+	"gorm.io/gorm"
+)
+
+const tenantSetting = "restapi:tenant"
+
+// TenantResolver returns the tenant of the request behind ctx.
+type TenantResolver func(ctx context.Context) (interface{}, error)
+
+type Tenanted[Entity interface{}] interface {
+	WithTenant(column string, resolve TenantResolver) Repository[Entity]
+}
+
+type tenancy struct {
+	column  string
+	resolve TenantResolver
+}
+
+// WithTenant returns a repository whose queries are restricted to a tenant.
+func (repo *GenericRepository[Entity]) WithTenant(column string, resolve TenantResolver) Repository[Entity] {
+	db := repo.db.Set(tenantSetting, tenancy{column: column, resolve: resolve}).Session(&gorm.Session{})
+	return NewRepository[Entity](db, repo.GenericRepository.GetModel())
+}
+
+func tenantScope(db *gorm.DB) (*Scope, error) {
+	v, ok := db.Get(tenantSetting)
+	if !ok {
+		return nil, nil
+	}
+
+	t := v.(tenancy)
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	tenant, err := t.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Scope{Column: t.column, Value: tenant}, nil
+}
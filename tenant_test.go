@@ -0,0 +1,79 @@
+package restapi_test
+
+import (
+	"context"
+	"errors"
+	"github.com/miniyus/go-restapi"
+	"gorm.io/gorm"
+	"testing"
+)
+
+type tenantKey struct{}
+
+func resolveTenant(ctx context.Context) (interface{}, error) {
+	tenant, ok := ctx.Value(tenantKey{}).(uint)
+	if !ok {
+		return nil, restapi.Forbidden(errors.New("missing tenant"))
+	}
+
+	return tenant, nil
+}
+
+func TestGenericRepository_WithTenant(t *testing.T) {
+	repo := restapi.NewRepository[TestRelationModel](db, TestRelationModel{}).WithTenant("test_entity_id", resolveTenant)
+	s := restapi.NewService[TestRelationModel, *TestRelationReq, *TestRelationRes](repo, &TestRelationRes{})
+
+	tenant1 := context.WithValue(context.Background(), tenantKey{}, uint(1))
+	tenant2 := context.WithValue(context.Background(), tenantKey{}, uint(2))
+
+	create, err := s.CreateContext(tenant1, &TestRelationReq{TestEntityId: 2, Seq: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if create.TestEntityId != 1 {
+		t.Errorf("expected the tenant to be stamped, got %+v", create)
+	}
+
+	if _, err = s.FindContext(tenant1, create.Id); err != nil {
+		t.Errorf("expected to find own record, got %v", err)
+	}
+
+	if _, err = s.FindContext(tenant2, create.Id); !errors.Is(err, restapi.ErrNotFound) {
+		t.Errorf("expected not found across tenants, got %v", err)
+	}
+
+	if _, err = s.DeleteContext(tenant2, create.Id); !errors.Is(err, restapi.ErrNotFound) {
+		t.Errorf("expected not found across tenants, got %v", err)
+	}
+
+	if _, err = s.Find(create.Id); !errors.Is(err, restapi.ErrForbidden) {
+		t.Errorf("expected queries without tenant to fail, got %v", err)
+	}
+
+	other := repo.WithContext(tenant2)
+	if _, err = other.Find(create.Id); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("expected Find to be scoped, got %v", err)
+	}
+
+	all, err := other.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ent := range all {
+		if ent.ID == create.Id {
+			t.Error("expected All to be scoped")
+		}
+	}
+
+	steal := TestRelationModel{Seq: 4}
+	steal.ID = create.Id
+	if _, err = other.Save(steal); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("expected Save to be scoped, got %v", err)
+	}
+
+	if _, err = other.Delete(create.Id); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("expected Delete to be scoped, got %v", err)
+	}
+}
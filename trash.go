@@ -0,0 +1,119 @@
+package restapi
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+	"reflect"
+)
+
+// TrashedMode selects soft deleted rows in listings, see ?trashed=.
+type TrashedMode string
+
+const (
+	WithoutTrashed TrashedMode = ""
+	WithTrashed    TrashedMode = "with"
+	OnlyTrashed    TrashedMode = "only"
+)
+
+var deletedAtType = reflect.TypeOf(gorm.DeletedAt{})
+
+func ParseTrashed(param string) (TrashedMode, error) {
+	switch mode := TrashedMode(param); mode {
+	case WithoutTrashed, WithTrashed, OnlyTrashed:
+		return mode, nil
+	}
+
+	return WithoutTrashed, BadRequest(fmt.Errorf("invalid trashed mode '%s', expected 'with' or 'only'", param))
+}
+
+// HasSoftDelete reports whether model has a gorm.DeletedAt field.
+func HasSoftDelete(model interface{}) bool {
+	sch, err := parseModel(model)
+	if err != nil {
+		return false
+	}
+
+	return softDeleteField(sch) != nil
+}
+
+func softDeleteField(sch *schema.Schema) *schema.Field {
+	for _, field := range sch.Fields {
+		if field.FieldType == deletedAtType && field.DBName != "" {
+			return field
+		}
+	}
+
+	return nil
+}
+
+func scopeTrashed(db *gorm.DB, mode TrashedMode) (*gorm.DB, error) {
+	if mode == WithoutTrashed {
+		return db, nil
+	}
+
+	sch, err := parseSchema(db, db.Statement.Model)
+	if err != nil {
+		return db, err
+	}
+
+	field := softDeleteField(sch)
+	if field == nil {
+		return db, BadRequest(errors.New("resource does not support soft delete"))
+	}
+
+	db = db.Unscoped()
+	if mode == OnlyTrashed {
+		db = db.Where(clause.Neq{
+			Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName},
+			Value:  nil,
+		})
+	}
+
+	return db, nil
+}
+
+// Unscoped returns a repository that sees soft deleted rows.
+func (repo *GenericRepository[Entity]) Unscoped() Repository[Entity] {
+	return NewRepository[Entity](repo.db.Unscoped().Session(&gorm.Session{}), repo.GenericRepository.GetModel())
+}
+
+// RestoreByKey clears the soft delete column of a trashed row.
+func (repo *GenericRepository[Entity]) RestoreByKey(key interface{}) (*Entity, error) {
+	model := repo.GenericRepository.GetModel()
+	sch, err := parseSchema(repo.db, &model)
+	if err != nil {
+		return nil, err
+	}
+
+	field := softDeleteField(sch)
+	if field == nil {
+		return nil, BadRequest(errors.New("resource does not support soft delete"))
+	}
+
+	err = repo.db.Transaction(func(tx *gorm.DB) error {
+		db, err := whereKey(tx.Unscoped().Model(&model), key)
+		if err != nil {
+			return err
+		}
+
+		if err = db.First(&model).Error; err != nil {
+			return err
+		}
+
+		deletedAt, _ := field.ValueOf(tx.Statement.Context, reflect.ValueOf(&model).Elem())
+		if v, ok := deletedAt.(gorm.DeletedAt); !ok || !v.Valid {
+			return Conflict(errors.New("resource is not trashed"))
+		}
+
+		return tx.Unscoped().Model(&model).Update(field.DBName, nil).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.FindByKey(key)
+}
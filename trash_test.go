@@ -0,0 +1,71 @@
+package restapi_test
+
+import (
+	"errors"
+	"github.com/miniyus/go-restapi"
+	"testing"
+)
+
+func TestParseTrashed(t *testing.T) {
+	testCases := map[string]restapi.TrashedMode{
+		"":     restapi.WithoutTrashed,
+		"with": restapi.WithTrashed,
+		"only": restapi.OnlyTrashed,
+	}
+
+	for param, expected := range testCases {
+		mode, err := restapi.ParseTrashed(param)
+		if err != nil || mode != expected {
+			t.Errorf("%s: expected %s, got %s (%v)", param, expected, mode, err)
+		}
+	}
+
+	_, err := restapi.ParseTrashed("all")
+	if !errors.Is(err, restapi.ErrBadRequest) {
+		t.Errorf("expected bad request, got %v", err)
+	}
+}
+
+func TestGenericService_Restore(t *testing.T) {
+	s := newTestService()
+
+	data := makeFakeData(1)[0]
+	create, err := s.Create(&data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = s.Delete(create.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	filter := restapi.Filter[TestEntity]{Trashed: restapi.OnlyTrashed}
+	trashed, err := s.All(&filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := false
+	for _, res := range trashed.Data {
+		found = found || res.Id == create.Id
+	}
+	if !found {
+		t.Errorf("deleted record %d not listed as trashed", create.Id)
+	}
+
+	if _, err = s.Restore(create.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = s.Restore(create.Id); !errors.Is(err, restapi.ErrConflict) {
+		t.Errorf("expected conflict restoring a live record, got %v", err)
+	}
+
+	if _, err = s.ForceDelete(create.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = s.Restore(create.Id); !errors.Is(err, restapi.ErrNotFound) {
+		t.Errorf("expected not found after force delete, got %v", err)
+	}
+}